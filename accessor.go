@@ -6,92 +6,467 @@ import (
 	"reflect"
 	"strconv"
 	"strings"
+	"sync"
 )
 
 // accessor is an interface for writing the value of ogp to variables.
 type accessor interface {
 	Set(key string, val string) error
+	// Finish is called after all values are set.
+	Finish() error
 }
 
+// decoder holds the options shared by the accessors of a value.
+type decoder struct {
+	opts  *ParserOpts
+	rules tagRules
+}
+
+// accessorKind is a kind of the accessor used for a type.
+type accessorKind int
+
+const (
+	valueKind accessorKind = iota
+	arrayKind
+	mapKind
+	structKind
+	genericKind
+	documentKind
+	unmarshalerKind
+)
+
 // valueAccessor is an accessor for writing the value of ogp to single variable.
 type valueAccessor struct {
-	value  reflect.Value
+	decoder *decoder
+	value   reflect.Value
+	policy  ConflictPolicy
+	// The raw value written first. It is used for detecting conflicts.
+	raw    string
 	didSet bool
+	// alternate writes the conflicting values when ConflictCollect is used.
+	alternate func(key string, val string) error
 }
 
 // arrayAccessor is an accessor for writing the values of ogp to an array or a slice.
 type arrayAccessor struct {
+	decoder *decoder
 	tag     *tag
 	value   reflect.Value
 	idx     int
 	current accessor
+	missing missingProperties
+	// If it is true, the array is full and later elements are dropped.
+	overflowed bool
 }
 
 // structAccessor is an accessor for writing the values of ogp to a struct.
 type structAccessor struct {
-	value  *reflect.Value
-	fields map[string]*field
+	decoder *decoder
+	// The name of the parent field, which the relative names are based on. (e.g. `og:image`)
+	root      string
+	value     reflect.Value
+	plan      *structPlan
+	accessors []accessor
+}
+
+// mapAccessor is an accessor for writing the values of ogp to a map which keys are the property names.
+type mapAccessor struct {
+	decoder *decoder
+	value   reflect.Value
+	// The accessor and the value of each key.
+	elems map[string]*mapElem
+}
+
+type mapElem struct {
+	ac    accessor
+	value reflect.Value
 }
 
-type field struct {
-	structField *reflect.StructField
-	tag         *tag
-	value       *reflect.Value
-	accessor    accessor
+// structPlan is a mapping plan from OGP properties to the fields of a struct type.
+// It is computed once for each type and cached.
+type structPlan struct {
+	fields []*fieldPlan
+	names  map[string]*fieldPlan
+	// The fields which have `alternates` option.
+	alternates map[string]*fieldPlan
+	// The fields which have wildcard patterns. The key is the prefix. (e.g. `al` for `al:*`, and empty for `*`)
+	patterns map[string]*fieldPlan
+	// The tags of the fields which the relative names are resolved, for each root.
+	resolved sync.Map // map[string][]*tag
+}
+
+// structPlanKey is a key of the cached plans.
+type structPlanKey struct {
+	t     reflect.Type
+	rules tagRules
+}
+
+// fieldPlan is a mapping plan of a field.
+type fieldPlan struct {
+	// The index in structPlan.fields.
+	order int
+	// The index sequence for reflect.Value.FieldByIndex, which has the indexes of the embedded structs.
+	index []int
+	// The type of the field.
+	typ reflect.Type
+	tag *tag
+	// The kind of the type (or the element type of the array), and the plan when it is a struct.
+	// They are computed once by fieldPlan.target.
+	targetOnce sync.Once
+	targetKind accessorKind
+	targetPlan *structPlan
+}
+
+var (
+	textUnmarshalerType = reflect.TypeOf((*encoding.TextUnmarshaler)(nil)).Elem()
+	structPlanCache     sync.Map // map[structPlanKey]*structPlan
+	defaultDecoder      = newDecoder(&ParserOpts{})
+)
+
+// newDecoder create a `*decoder` with the options.
+func newDecoder(opts *ParserOpts) *decoder {
+	rules := tagRules{
+		key:              opts.TagKey,
+		namespace:        opts.DefaultNamespace,
+		disableInference: opts.DisableInference,
+	}
+	if rules.key == "" {
+		rules.key = structTagKey
+	}
+	if rules.namespace == "" {
+		rules.namespace = defaultNamespace
+	}
+	return &decoder{opts: opts, rules: rules}
 }
 
 func newAccessor(tag *tag, v reflect.Value) accessor {
-	iv := reflect.Indirect(v)
-	switch iv.Kind() {
+	return defaultDecoder.newAccessor(tag, v)
+}
+
+func (d *decoder) newAccessor(tag *tag, v reflect.Value) accessor {
+	// NOTE: Nil pointers are allocated, since the accessor is created just before setting a value.
+	iv := v
+	for {
+		// NOTE: Like encoding/json, a non-nil pointer stored in the interface is used.
+		if iv.Kind() == reflect.Interface && !iv.IsNil() && iv.Elem().Kind() == reflect.Ptr && !iv.Elem().IsNil() {
+			iv = iv.Elem()
+			continue
+		}
+		if iv.Kind() != reflect.Ptr {
+			break
+		}
+		if iv.IsNil() {
+			if !iv.CanSet() {
+				break
+			}
+			iv.Set(reflect.New(iv.Type().Elem()))
+		}
+		iv = iv.Elem()
+	}
+
+	if !iv.IsValid() {
+		return d.newValueAccessor(tag, iv)
+	}
+
+	switch d.typeKind(iv.Type()) {
+	case unmarshalerKind:
+		if iv.CanAddr() {
+			return &unmarshalerAccessor{unmarshaler: iv.Addr().Interface().(Unmarshaler)}
+		}
+	case genericKind:
+		return &genericAccessor{tag: tag, value: iv}
+	case arrayKind:
+		return &arrayAccessor{decoder: d, tag: tag, value: iv}
+	case mapKind:
+		return &mapAccessor{decoder: d, value: iv, elems: make(map[string]*mapElem)}
+	case documentKind:
+		if iv.CanAddr() {
+			return &documentAccessor{doc: iv.Addr().Interface().(*Document)}
+		}
+	case structKind:
+		if iv.CanAddr() {
+			plan := cachedStructPlan(iv.Type(), d.rules)
+			return &structAccessor{decoder: d, root: rootName(tag), value: iv, plan: plan, accessors: make([]accessor, len(plan.fields))}
+		}
+	}
+	return d.newValueAccessor(tag, iv)
+}
+
+// typeKind returns the kind of the accessor used for the type.
+func (d *decoder) typeKind(t reflect.Type) accessorKind {
+	switch {
+	case reflect.PtrTo(t).Implements(unmarshalerType):
+		return unmarshalerKind
+	case isGenericType(t):
+		return genericKind
+	}
+
+	switch t.Kind() {
 	case reflect.Array, reflect.Slice:
-		return &arrayAccessor{tag: tag, value: iv}
+		return arrayKind
+	case reflect.Map:
+		if t.Key().Kind() == reflect.String {
+			return mapKind
+		}
 	case reflect.Struct:
-		if iv.CanAddr() {
-			if iv.Addr().Type().Implements(reflect.TypeOf((*encoding.TextUnmarshaler)(nil)).Elem()) {
-				return newValueAccessor(v)
-			}
+		if t == documentType {
+			return documentKind
+		}
+		if !reflect.PtrTo(t).Implements(textUnmarshalerType) && len(cachedStructPlan(t, d.rules).fields) > 0 {
+			return structKind
 		}
+	}
+	return valueKind
+}
 
-		t := iv.Type()
-		fields := make(map[string]*field)
-		for i := 0; i < t.NumField(); i++ {
-			structField := t.Field(i)
-			fieldValue := iv.Field(i)
-			if !fieldValue.CanSet() {
-				continue
+// accepts returns true, when the type of the field has a field for the key, or it accepts any properties (e.g. map).
+// The name of root is the name of the field, which the relative names of the struct are based on.
+func (d *decoder) accepts(f *fieldPlan, root string, key string, visited map[*structPlan]bool) bool {
+	kind, plan := f.target(d)
+	switch kind {
+	case structKind:
+		// NOTE: Recursive types accept nothing more than the first one.
+		if visited[plan] {
+			return false
+		}
+		if visited == nil {
+			visited = make(map[*structPlan]bool)
+		}
+		visited[plan] = true
+		defer delete(visited, plan)
+
+		ac := structAccessor{decoder: d, root: root, plan: plan}
+		_, ok := ac.route(key, visited)
+		return ok
+	case valueKind:
+		return false
+	default:
+		return true
+	}
+}
+
+// target returns the kind of the field type (or the element type of the array), and the plan when it is a struct.
+//
+// NOTE: The decoder must have the same tag rules as the plan of the field.
+func (f *fieldPlan) target(d *decoder) (accessorKind, *structPlan) {
+	f.targetOnce.Do(func() {
+		t := indirectType(f.typ)
+		kind := d.typeKind(t)
+		if kind == arrayKind {
+			t = indirectType(t.Elem())
+			kind = d.typeKind(t)
+		}
+		f.targetKind = kind
+		if kind == structKind {
+			f.targetPlan = cachedStructPlan(t, d.rules)
+		}
+	})
+	return f.targetKind, f.targetPlan
+}
+
+// rootName returns the name which the relative names of the nested struct are based on.
+func rootName(tag *tag) string {
+	if tag == nil || len(tag.names) == 0 {
+		return ""
+	}
+	if _, ok := patternPrefix(tag.names[0]); ok {
+		return ""
+	}
+	return tag.names[0]
+}
+
+// report notifies the diagnostic to ParserOpts.OnDiagnostic.
+// It returns DiagnosticError in strict mode.
+func (d *decoder) report(diag *Diagnostic) error {
+	if f := d.opts.OnDiagnostic; f != nil {
+		f(diag)
+	}
+	if d.opts.Strict {
+		return &DiagnosticError{Diagnostic: diag}
+	}
+	return nil
+}
+
+// cachedStructPlan returns the plan of the struct type with the tag rules.
+func cachedStructPlan(t reflect.Type, rules tagRules) *structPlan {
+	key := structPlanKey{t: t, rules: rules}
+	if plan, ok := structPlanCache.Load(key); ok {
+		return plan.(*structPlan)
+	}
+	plan, _ := structPlanCache.LoadOrStore(key, newStructPlan(t, rules))
+	return plan.(*structPlan)
+}
+
+// resolvedTags returns the tags of the fields which the relative names are resolved with the root.
+// They are indexed by fieldPlan.order, and cached for each root.
+func (plan *structPlan) resolvedTags(root string) []*tag {
+	if tags, ok := plan.resolved.Load(root); ok {
+		return tags.([]*tag)
+	}
+	tags := make([]*tag, len(plan.fields))
+	for i, f := range plan.fields {
+		tags[i] = f.tag.resolve(root)
+	}
+	resolved, _ := plan.resolved.LoadOrStore(root, tags)
+	return resolved.([]*tag)
+}
+
+// newStructPlan returns the plan of the struct type.
+//
+// Like encoding/json, the fields of the embedded structs without the names are promoted to the struct.
+// See dominantField for the field used when the fields have the same name.
+func newStructPlan(t reflect.Type, rules tagRules) *structPlan {
+	plan := &structPlan{}
+	plan.addFields(t, nil, rules, map[reflect.Type]bool{})
+
+	names := make(map[string][]*fieldPlan)
+	alternates := make(map[string][]*fieldPlan)
+	patterns := make(map[string][]*fieldPlan)
+	for _, field := range plan.fields {
+		for _, name := range field.tag.names {
+			prefix, ok := patternPrefix(name)
+			switch {
+			case field.tag.alternates:
+				alternates[name] = append(alternates[name], field)
+			case ok:
+				patterns[prefix] = append(patterns[prefix], field)
+			default:
+				names[name] = append(names[name], field)
 			}
+		}
+	}
 
-			tag := newTag(structField)
-			field := &field{
-				structField: &structField,
-				tag:         newTag(structField),
-				value:       &fieldValue,
+	plan.names = dominantFields(names)
+	plan.alternates = dominantFields(alternates)
+	plan.patterns = dominantFields(patterns)
+	return plan
+}
+
+// dominantFields returns the field used for each name.
+func dominantFields(candidates map[string][]*fieldPlan) map[string]*fieldPlan {
+	fields := make(map[string]*fieldPlan)
+	for name, candidate := range candidates {
+		i := dominantField(len(candidate), func(i int) (int, bool) {
+			return len(candidate[i].index) - 1, candidate[i].tag.inferred
+		})
+		if i >= 0 {
+			fields[name] = candidate[i]
+		}
+	}
+	return fields
+}
+
+// addFields adds the fields of the struct type, and the promoted fields of the embedded structs.
+func (plan *structPlan) addFields(t reflect.Type, index []int, rules tagRules, visited map[reflect.Type]bool) {
+	visited[t] = true
+	defer delete(visited, t)
+
+	for i := 0; i < t.NumField(); i++ {
+		structField := t.Field(i)
+		fieldIndex := append(append([]int{}, index...), i)
+
+		if structField.Anonymous {
+			ft := indirectType(structField.Type)
+			// NOTE: Unexported embedded fields can be used only when the type is a struct, since they cannot be allocated.
+			if structField.PkgPath != "" && (structField.Type.Kind() == reflect.Ptr || ft.Kind() != reflect.Struct) {
+				continue
 			}
 
-			for _, name := range tag.names {
-				if _, ok := fields[name]; !ok {
-					fields[name] = field
+			tag := rules.newTag(structField)
+			if tag.isInline() && isInlineType(ft) {
+				if !visited[ft] {
+					plan.addFields(ft, fieldIndex, rules, visited)
 				}
+				continue
+			}
+			if structField.PkgPath != "" {
+				continue
 			}
+			plan.fields = append(plan.fields, &fieldPlan{order: len(plan.fields), index: fieldIndex, typ: structField.Type, tag: tag})
+			continue
 		}
 
-		if len(fields) == 0 {
-			return newValueAccessor(v)
+		// NOTE: Unexported fields cannot be set.
+		if structField.PkgPath != "" {
+			continue
+		}
+		plan.fields = append(plan.fields, &fieldPlan{order: len(plan.fields), index: fieldIndex, typ: structField.Type, tag: rules.newTag(structField)})
+	}
+}
+
+// isInlineType returns true, when the fields of the embedded type are promoted.
+func isInlineType(t reflect.Type) bool {
+	return t.Kind() == reflect.Struct &&
+		t != documentType &&
+		!reflect.PtrTo(t).Implements(textUnmarshalerType) &&
+		!reflect.PtrTo(t).Implements(unmarshalerType)
+}
+
+// dominantField returns the index of the field used among the fields which have the same name in order of declaration.
+// rank returns the depth of the embedded structs and whether the name is inferred of each field.
+//
+// Like encoding/json, the shallowest field is used, and then the field which has the tag is used.
+// If the promoted fields are still ambiguous, it returns -1 and none of them are used.
+// NOTE: The first one is used when the fields of the struct itself are ambiguous, for compatibility.
+func dominantField(n int, rank func(i int) (depth int, inferred bool)) int {
+	dominant, ambiguous := -1, false
+	for i := 0; i < n; i++ {
+		if dominant < 0 {
+			dominant = i
+			continue
+		}
+
+		depth, inferred := rank(i)
+		dominantDepth, dominantInferred := rank(dominant)
+		switch {
+		case depth < dominantDepth || (depth == dominantDepth && !inferred && dominantInferred):
+			dominant, ambiguous = i, false
+		case depth == dominantDepth && inferred == dominantInferred:
+			ambiguous = true
 		}
-		return &structAccessor{value: &v, fields: fields}
-	default:
-		return newValueAccessor(v)
 	}
+
+	if depth, _ := rank(dominant); ambiguous && depth > 0 {
+		return -1
+	}
+	return dominant
 }
 
 func newValueAccessor(v reflect.Value) *valueAccessor {
-	return &valueAccessor{value: v}
+	return defaultDecoder.newValueAccessor(nil, v)
+}
+
+func (d *decoder) newValueAccessor(tag *tag, v reflect.Value) *valueAccessor {
+	policy := d.opts.ConflictPolicy
+	if tag != nil && tag.conflict != nil {
+		policy = *tag.conflict
+	}
+	return &valueAccessor{decoder: d, value: v, policy: policy}
 }
 
 func (f *valueAccessor) Set(key string, val string) error {
-	// NOTE: The first tag (from top to bottom) is given preference during conflicts.
 	if f.didSet {
-		return nil
+		if val == f.raw {
+			return nil
+		}
+
+		switch f.policy {
+		case ConflictLast:
+			// NOTE: The overwritten value is reported.
+			if err := f.decoder.report(&Diagnostic{Kind: DiagnosticConflict, Property: key, Value: f.raw}); err != nil {
+				return err
+			}
+		case ConflictFail:
+			return &ConflictError{Property: key, Values: []string{f.raw, val}}
+		case ConflictCollect:
+			if f.alternate != nil {
+				return f.alternate(key, val)
+			}
+			fallthrough
+		default:
+			// NOTE: The first tag (from top to bottom) is given preference during conflicts.
+			return f.decoder.report(&Diagnostic{Kind: DiagnosticConflict, Property: key, Value: val})
+		}
 	}
 
 	if !f.value.IsValid() {
@@ -144,52 +519,287 @@ func (f *valueAccessor) Set(key string, val string) error {
 		}
 	}
 
+	f.raw = val
 	f.didSet = true
 	return nil
 }
 
+func (f *valueAccessor) Finish() error {
+	return nil
+}
+
 func (f *arrayAccessor) Set(key string, val string) error {
-	if f.current != nil && f.tag != nil && !f.tag.isContainsName(key) {
+	isRoot := f.tag == nil || f.tag.isContainsName(key)
+	if f.overflowed {
+		// NOTE: The sub-properties of the dropped elements are also dropped.
+		if isRoot {
+			return f.decoder.report(&Diagnostic{Kind: DiagnosticOverflow, Property: key, Value: val})
+		}
+		return nil
+	}
+
+	if f.current != nil && !isRoot {
 		return f.current.Set(key, val)
 	}
 
 	if f.current != nil {
+		if err := f.missing.merge(f.current.Finish()); err != nil {
+			return err
+		}
+		f.current = nil
 		f.idx += 1
 	}
 
 	if f.idx >= f.value.Len() {
-		if f.value.Kind() == reflect.Slice {
-			if !f.value.CanSet() {
-				return fmt.Errorf("Cannot set to value")
-			}
-			v := reflect.New(f.value.Type().Elem()).Elem()
-			f.value.Set(reflect.Append(f.value, v))
-		} else {
-			return nil
+		if f.value.Kind() != reflect.Slice || (f.maxLen() > 0 && f.idx >= f.maxLen()) {
+			f.overflowed = true
+			return f.decoder.report(&Diagnostic{Kind: DiagnosticOverflow, Property: key, Value: val})
+		}
+		if !f.value.CanSet() {
+			return fmt.Errorf("Cannot set to value")
 		}
+		v := reflect.New(f.value.Type().Elem()).Elem()
+		f.value.Set(reflect.Append(f.value, v))
 	}
 
-	f.current = newAccessor(nil, f.value.Index(f.idx))
+	f.current = f.decoder.newAccessor(f.tag, f.value.Index(f.idx))
 	return f.current.Set(key, val)
 }
 
+// maxLen returns the maximum number of elements of the slice. It returns 0 when it is unlimited.
+func (f *arrayAccessor) maxLen() int {
+	if f.tag != nil && f.tag.max > 0 {
+		return f.tag.max
+	}
+	return f.decoder.opts.MaxElements
+}
+
+func (f *arrayAccessor) Finish() error {
+	if f.current != nil {
+		if err := f.missing.merge(f.current.Finish()); err != nil {
+			return err
+		}
+	}
+	return f.missing.errorOrNil()
+}
+
+// Set writes the value to the field which has the longest name matched to the key.
+// The absolute name is preferred to the relative name (e.g. `:width`) when they are the same length.
+//
+// NOTE: The sub-property which is not accepted by any field (e.g. `og:locale:alternate` for `Locale string`) is ignored,
+// so that it is not regarded as a conflict of the parent property.
 func (ac *structAccessor) Set(key string, val string) error {
-	parts := strings.Split(key, ":")
-	for i := len(parts); i >= 0; i-- {
-		k := strings.Join(parts[0:i], ":")
-		if f := ac.fields[k]; f != nil {
-			if f.accessor == nil {
-				if f.value.Kind() == reflect.Ptr && f.value.IsNil() {
-					f.value.Set(reflect.New(f.value.Type().Elem()))
+	if f, ok := ac.route(key, nil); ok {
+		return ac.fieldAccessor(f).Set(key, val)
+	}
+	return nil
+}
+
+// route returns the field which the key is written to, and whether the field accepts the key.
+//
+// The exact name (e.g. `og:image:width`) is preferred to a wildcard pattern (e.g. `og:image:*`).
+// The name of the parent (e.g. `og:image`) is preferred to the pattern only when the field accepts the key
+// (e.g. `[]googp.Image` accepts `og:image:width`), otherwise the pattern is preferred.
+// When no field accepts the key, it returns the nearest parent which does not accept the key (e.g. `Locale` for `og:locale:alternate`).
+func (ac *structAccessor) route(key string, visited map[*structPlan]bool) (*fieldPlan, bool) {
+	var fallback *fieldPlan
+	for k := key; ; k = parentKey(k) {
+		f := ac.lookup(ac.plan.names, k)
+		accepted := f != nil && (k == key || ac.decoder.accepts(f, rootName(ac.fieldTag(f)), key, visited))
+		// NOTE: The catch-all field of the embedded struct (e.g. Document) is not preferred to `*`.
+		if accepted && k != "" {
+			return f, true
+		}
+		if k != key {
+			if p := ac.lookup(ac.plan.patterns, k); p != nil {
+				return p, true
+			}
+		}
+		if accepted {
+			return f, true
+		}
+		if fallback == nil {
+			fallback = f
+		}
+		if k == "" {
+			return fallback, false
+		}
+	}
+}
+
+// lookup returns the field which has the name in the absolute form or the relative form.
+func (ac *structAccessor) lookup(fields map[string]*fieldPlan, name string) *fieldPlan {
+	if f := fields[name]; f != nil {
+		return f
+	}
+	if rel, ok := ac.relativeName(name); ok {
+		return fields[rel]
+	}
+	return nil
+}
+
+// relativeName returns the name relative to the root. (e.g. `:width` for `og:image:width`, and `:` for `og:image`)
+// If the name is not under the root, it returns false.
+func (ac *structAccessor) relativeName(name string) (string, bool) {
+	switch {
+	case ac.root == "":
+		return "", false
+	case name == ac.root:
+		return ":", true
+	case strings.HasPrefix(name, ac.root+":"):
+		return name[len(ac.root):], true
+	default:
+		return "", false
+	}
+}
+
+// absoluteName returns the name which the relative name is resolved. (e.g. `og:image:width` for `:width`)
+func (ac *structAccessor) absoluteName(name string) string {
+	return resolveName(ac.root, name)
+}
+
+// fieldTag returns the tag of the field which the relative names are resolved.
+func (ac *structAccessor) fieldTag(f *fieldPlan) *tag {
+	if !f.tag.hasRelativeName() || ac.root == "" {
+		return f.tag
+	}
+	return ac.plan.resolvedTags(ac.root)[f.order]
+}
+
+// Finish applies the default values to the absent properties, and checks the required properties.
+func (ac *structAccessor) Finish() error {
+	var missing missingProperties
+	for _, f := range ac.plan.fields {
+		if ac.accessors[f.order] == nil && len(f.tag.names) > 0 {
+			if f.tag.defaultValue != nil {
+				if err := ac.fieldAccessor(f).Set(ac.absoluteName(f.tag.names[0]), *f.tag.defaultValue); err != nil {
+					return err
 				}
-				f.accessor = newAccessor(f.tag, *f.value)
+			} else if f.tag.required {
+				missing = append(missing, ac.absoluteName(f.tag.names[0]))
+				continue
+			}
+		}
+
+		if ac.accessors[f.order] != nil {
+			if err := missing.merge(ac.accessors[f.order].Finish()); err != nil {
+				return err
+			}
+		}
+	}
+	return missing.errorOrNil()
+}
+
+// fieldAccessor returns the accessor of the field, and creates it when it does not exist.
+func (ac *structAccessor) fieldAccessor(f *fieldPlan) accessor {
+	if ac.accessors[f.order] == nil {
+		fieldAccessor := ac.decoder.newAccessor(ac.fieldTag(f), ac.fieldValue(f))
+		if va, ok := fieldAccessor.(*valueAccessor); ok && va.policy == ConflictCollect {
+			va.alternate = ac.alternateSetter(f)
+		}
+		ac.accessors[f.order] = fieldAccessor
+	}
+	return ac.accessors[f.order]
+}
+
+// fieldValue returns the value of the field, and allocates the nil pointers of the embedded structs.
+func (ac *structAccessor) fieldValue(f *fieldPlan) reflect.Value {
+	v := ac.value
+	for i, index := range f.index {
+		if i > 0 && v.Kind() == reflect.Ptr {
+			if v.IsNil() {
+				v.Set(reflect.New(v.Type().Elem()))
+			}
+			v = v.Elem()
+		}
+		v = v.Field(index)
+	}
+	return v
+}
+
+// alternateSetter returns a function to write the conflicting values to the field which has `alternates` option.
+// It returns nil when the field does not exist.
+func (ac *structAccessor) alternateSetter(f *fieldPlan) func(key string, val string) error {
+	for _, name := range f.tag.names {
+		if alt := ac.plan.alternates[name]; alt != nil {
+			return func(key string, val string) error {
+				return ac.fieldAccessor(alt).Set(key, val)
 			}
-			return f.accessor.Set(key, val)
 		}
 	}
 	return nil
 }
 
+func (ac *mapAccessor) Set(key string, val string) error {
+	if ac.value.IsNil() {
+		if !ac.value.CanSet() {
+			return fmt.Errorf("Cannot set to value")
+		}
+		ac.value.Set(reflect.MakeMap(ac.value.Type()))
+	}
+
+	elem := ac.elems[key]
+	if elem == nil {
+		// NOTE: The map element is not addressable, so it is written to the map after setting to the copy.
+		v := reflect.New(ac.value.Type().Elem())
+		if current := ac.value.MapIndex(reflect.ValueOf(key).Convert(ac.value.Type().Key())); current.IsValid() {
+			v.Elem().Set(current)
+		}
+		elem = &mapElem{ac: ac.decoder.newAccessor(&tag{names: []string{key}}, v), value: v.Elem()}
+		ac.elems[key] = elem
+	}
+
+	if err := elem.ac.Set(key, val); err != nil {
+		return err
+	}
+	ac.value.SetMapIndex(reflect.ValueOf(key).Convert(ac.value.Type().Key()), elem.value)
+	return nil
+}
+
+func (ac *mapAccessor) Finish() error {
+	var missing missingProperties
+	for key, elem := range ac.elems {
+		if err := missing.merge(elem.ac.Finish()); err != nil {
+			return err
+		}
+		ac.value.SetMapIndex(reflect.ValueOf(key).Convert(ac.value.Type().Key()), elem.value)
+	}
+	return missing.errorOrNil()
+}
+
+// resolveName returns the name which the relative name is resolved with the root.
+// If the root is empty, it returns the name as it is.
+func resolveName(root string, name string) string {
+	if !isRelativeName(name) || root == "" {
+		return name
+	}
+	if name == ":" {
+		return root
+	}
+	return root + name
+}
+
+// patternPrefix returns the prefix of the wildcard pattern. (e.g. `al` for `al:*`, and empty for `*`)
+// If the name is not a pattern, it returns false.
+func patternPrefix(name string) (string, bool) {
+	if name == "*" {
+		return "", true
+	}
+	if strings.HasSuffix(name, ":*") {
+		return strings.TrimSuffix(name, ":*"), true
+	}
+	return "", false
+}
+
+// parentKey returns the key without the last part separated by `:`. (e.g. `og:image:url` -> `og:image`)
+// It returns an empty string when the key has only one part.
+func parentKey(key string) string {
+	if i := strings.LastIndex(key, ":"); i >= 0 {
+		return key[:i]
+	}
+	return ""
+}
+
 func convertErr(key string, val string, ty reflect.Type) error {
 	return fmt.Errorf("%s field is invalid. (type = %s, value = %s)", key, ty.Name(), val)
 }
@@ -197,3 +807,22 @@ func convertErr(key string, val string, ty reflect.Type) error {
 func unsupportedErr(key string, ty reflect.Type) error {
 	return fmt.Errorf("%s is unsupported type (field = %s)", ty.Name(), key)
 }
+
+// missingProperties is the names of the required properties which are absent.
+type missingProperties []string
+
+// merge appends the properties when the err is MissingRequiredError, otherwise it returns the err.
+func (m *missingProperties) merge(err error) error {
+	if missingErr, ok := err.(*MissingRequiredError); ok {
+		*m = append(*m, missingErr.Properties...)
+		return nil
+	}
+	return err
+}
+
+func (m missingProperties) errorOrNil() error {
+	if len(m) == 0 {
+		return nil
+	}
+	return &MissingRequiredError{Properties: m}
+}
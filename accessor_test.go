@@ -1,7 +1,9 @@
 package googp
 
 import (
+	"errors"
 	"reflect"
+	"sync"
 	"testing"
 	"time"
 )
@@ -194,3 +196,485 @@ func Test_StructAccessor_Private(t *testing.T) {
 	assertNoError(t, ac.Set("og:title", "title"))
 	assertEqual(t, og2.ogp, (*OGP)(nil))
 }
+
+func Test_StructPlan(t *testing.T) {
+	plan := cachedStructPlan(reflect.TypeOf(Image{}), defaultTagRules)
+	assertEqual(t, plan == cachedStructPlan(reflect.TypeOf(Image{}), defaultTagRules), true)
+	assertEqual(t, len(plan.fields), 6)
+	assertEqual(t, plan.names["og:image"] == plan.fields[0], true)
+	assertEqual(t, plan.names["og:image:url"] == plan.fields[0], true)
+	assertEqual(t, plan.names["og:image:alt"] == plan.fields[5], true)
+
+	var v struct {
+		a string
+		B string `googp:"og:title"`
+		C string `googp:"og:title"`
+		D string `googp:"-"`
+	}
+	plan = cachedStructPlan(reflect.TypeOf(v), defaultTagRules)
+	assertEqual(t, len(plan.fields), 3)
+	assertEqual(t, plan.names["og:title"].index, []int{1})
+	assertEqual(t, len(plan.names), 1)
+}
+
+func Test_StructPlan_ResolvedTags(t *testing.T) {
+	plan := cachedStructPlan(reflect.TypeOf(Image{}), defaultTagRules)
+	tags := plan.resolvedTags("twitter:image")
+	assertEqual(t, &tags[0] == &plan.resolvedTags("twitter:image")[0], true)
+	assertEqual(t, tags[0].names, []string{"og:image", "og:image:url", "twitter:image", "twitter:image:url"})
+	assertEqual(t, plan.resolvedTags("og:image")[0].names, []string{"og:image", "og:image:url"})
+
+	var ogp OGP
+	ac := newAccessor(nil, reflect.ValueOf(&ogp))
+	assertNoError(t, ac.Set("og:image", "http://example.com/image.png"))
+	allocs := testing.AllocsPerRun(100, func() {
+		_, ok := ac.(*structAccessor).route("og:image:width", nil)
+		assertEqual(t, ok, true)
+	})
+	assertEqual(t, allocs, float64(0))
+}
+
+func Test_StructAccessor_Concurrent(t *testing.T) {
+	var wg sync.WaitGroup
+	for i := 0; i < 8; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			var ogp OGP
+			ac := newAccessor(nil, reflect.ValueOf(&ogp))
+			assertNoError(t, ac.Set("og:image", "http://example.com/image.png"))
+			assertNoError(t, ac.Set("og:image:width", "400"))
+			assertEqual(t, ogp.Images, []Image{{URL: "http://example.com/image.png", Width: 400}})
+		}()
+	}
+	wg.Wait()
+}
+
+func Test_StructAccessor_Required(t *testing.T) {
+	type Image struct {
+		URL  string `googp:"og:image,og:image:url,required"`
+		Type string `googp:"og:image:type,required"`
+	}
+	var v struct {
+		Title  string  `googp:"og:title,required"`
+		Type   string  `googp:"og:type,required"`
+		Images []Image `googp:"og:image"`
+	}
+
+	ac := newAccessor(nil, reflect.ValueOf(&v))
+	assertNoError(t, ac.Set("og:title", "title"))
+	assertNoError(t, ac.Set("og:image", "http://example.com/image1.png"))
+	assertNoError(t, ac.Set("og:image", "http://example.com/image2.png"))
+	assertNoError(t, ac.Set("og:image:type", "image/png"))
+
+	err := ac.Finish()
+	missingErr, ok := err.(*MissingRequiredError)
+	assertEqual(t, ok, true)
+	assertEqual(t, missingErr.Properties, []string{"og:type", "og:image:type"})
+	assertEqual(t, err.Error(), "Required properties are missing (og:type, og:image:type)")
+
+	var images []Image
+	ac = newAccessor(&tag{names: []string{"og:image"}}, reflect.ValueOf(&images))
+	assertNoError(t, ac.Set("og:image", "http://example.com/image3.png"))
+	assertNoError(t, ac.Set("og:image:type", "image/png"))
+	assertNoError(t, ac.Finish())
+}
+
+func Test_StructAccessor_Default(t *testing.T) {
+	var v struct {
+		Type   string   `googp:"og:type,default=website"`
+		Width  int      `googp:"og:image:width,default=1200"`
+		Height *int     `googp:"og:image:height,default=630"`
+		Locale []string `googp:"og:locale,default=en_US"`
+		Title  string   `googp:"og:title,required,default=a,b"`
+	}
+
+	ac := newAccessor(nil, reflect.ValueOf(&v))
+	assertNoError(t, ac.Set("og:type", "article"))
+	assertNoError(t, ac.Finish())
+	assertEqual(t, v.Type, "article")
+	assertEqual(t, v.Width, 1200)
+	assertEqual(t, *v.Height, 630)
+	assertEqual(t, v.Locale, []string{"en_US"})
+	assertEqual(t, v.Title, "a,b")
+
+	var invalid struct {
+		Width int `googp:"og:image:width,default=wide"`
+	}
+	ac = newAccessor(nil, reflect.ValueOf(&invalid))
+	assertError(t, ac.Finish())
+}
+
+func Test_ArrayAccessor_Overflow(t *testing.T) {
+	type Image struct {
+		URL   string `googp:"og:image"`
+		Width int    `googp:"og:image:width"`
+	}
+
+	var diags []*Diagnostic
+	d := newDecoder(&ParserOpts{OnDiagnostic: func(d *Diagnostic) { diags = append(diags, d) }})
+
+	var arr [1]Image
+	ac := d.newAccessor(&tag{names: []string{"og:image"}}, reflect.ValueOf(&arr))
+	assertNoError(t, ac.Set("og:image", "http://example.com/image1.png"))
+	assertNoError(t, ac.Set("og:image:width", "100"))
+	assertNoError(t, ac.Set("og:image", "http://example.com/image2.png"))
+	assertNoError(t, ac.Set("og:image:width", "200"))
+	assertNoError(t, ac.Finish())
+	assertEqual(t, arr, [1]Image{{URL: "http://example.com/image1.png", Width: 100}})
+	assertEqual(t, diags, []*Diagnostic{
+		{Kind: DiagnosticOverflow, Property: "og:image", Value: "http://example.com/image2.png"},
+	})
+
+	diags = nil
+	d.opts.MaxElements = 2
+	var s []string
+	ac = d.newAccessor(&tag{names: []string{"og:locale"}}, reflect.ValueOf(&s))
+	for _, locale := range []string{"en_US", "ja_JP", "fr_FR", "de_DE"} {
+		assertNoError(t, ac.Set("og:locale", locale))
+	}
+	assertEqual(t, s, []string{"en_US", "ja_JP"})
+	assertEqual(t, len(diags), 2)
+
+	s = nil
+	ac = d.newAccessor(&tag{names: []string{"og:locale"}, max: 3}, reflect.ValueOf(&s))
+	for _, locale := range []string{"en_US", "ja_JP", "fr_FR", "de_DE"} {
+		assertNoError(t, ac.Set("og:locale", locale))
+	}
+	assertEqual(t, s, []string{"en_US", "ja_JP", "fr_FR"})
+
+	d.opts.Strict = true
+	s = nil
+	ac = d.newAccessor(&tag{names: []string{"og:locale"}, max: 1}, reflect.ValueOf(&s))
+	assertNoError(t, ac.Set("og:locale", "en_US"))
+	err := ac.Set("og:locale", "ja_JP")
+	diagErr, ok := err.(*DiagnosticError)
+	assertEqual(t, ok, true)
+	assertEqual(t, diagErr.Diagnostic.Kind, DiagnosticOverflow)
+	assertEqual(t, err.Error(), "og:locale was dropped because it exceeds the capacity (value = ja_JP)")
+}
+
+func Test_Accessor_Pointers(t *testing.T) {
+	var v struct {
+		Images    []*Image   `googp:"og:image"`
+		Videos    *[]*Video  `googp:"og:video"`
+		Audios    [2]*Audio  `googp:"og:audio"`
+		Title     **string   `googp:"og:title"`
+		Locales   *[]string  `googp:"og:locale"`
+		Canonical *URL       `googp:"og:url"`
+		Empty     *[]*string `googp:"og:empty"`
+	}
+
+	ac := newAccessor(nil, reflect.ValueOf(&v))
+	assertNoError(t, ac.Set("og:title", "title"))
+	assertNoError(t, ac.Set("og:url", "http://example.com"))
+	assertNoError(t, ac.Set("og:image", "http://example.com/image1.png"))
+	assertNoError(t, ac.Set("og:image:width", "100"))
+	assertNoError(t, ac.Set("og:image", "http://example.com/image2.png"))
+	assertNoError(t, ac.Set("og:video", "http://example.com/video.mp4"))
+	assertNoError(t, ac.Set("og:video:type", "video/mp4"))
+	assertNoError(t, ac.Set("og:audio", "http://example.com/audio1.mp3"))
+	assertNoError(t, ac.Set("og:audio", "http://example.com/audio2.mp3"))
+	assertNoError(t, ac.Set("og:audio", "http://example.com/audio3.mp3"))
+	assertNoError(t, ac.Set("og:locale", "en_US"))
+	assertNoError(t, ac.Set("og:locale", "ja_JP"))
+	assertNoError(t, ac.Finish())
+
+	assertEqual(t, **v.Title, "title")
+	assertEqual(t, v.Canonical.String(), "http://example.com")
+	assertEqual(t, v.Images, []*Image{
+		{URL: "http://example.com/image1.png", Width: 100},
+		{URL: "http://example.com/image2.png"},
+	})
+	assertEqual(t, *v.Videos, []*Video{{URL: "http://example.com/video.mp4", Type: "video/mp4"}})
+	assertEqual(t, v.Audios, [2]*Audio{
+		{URL: "http://example.com/audio1.mp3"},
+		{URL: "http://example.com/audio2.mp3"},
+	})
+	assertEqual(t, *v.Locales, []string{"en_US", "ja_JP"})
+	assertEqual(t, v.Empty == nil, true)
+
+	// Nil pointers which cannot be set are not allocated.
+	var p *string
+	assertError(t, newAccessor(nil, reflect.ValueOf(p)).Set("og:title", "title"))
+}
+
+func Test_StructAccessor_Pattern(t *testing.T) {
+	type Image struct {
+		URL   string            `googp:"og:image"`
+		Width int               `googp:"og:image:width"`
+		Extra map[string]string `googp:"og:image:*"`
+	}
+	var v struct {
+		Title    string              `googp:"og:title"`
+		Images   []Image             `googp:"og:image"`
+		AppLinks map[string][]string `googp:"al:*"`
+		IOS      map[string]string   `googp:"al:ios"`
+		Others   map[string]string   `googp:"*"`
+	}
+
+	ac := newAccessor(nil, reflect.ValueOf(&v))
+	assertNoError(t, ac.Set("og:title", "title"))
+	assertNoError(t, ac.Set("og:image", "http://example.com/image1.png"))
+	assertNoError(t, ac.Set("og:image:width", "100"))
+	assertNoError(t, ac.Set("og:image:alt", "alt1"))
+	assertNoError(t, ac.Set("og:image", "http://example.com/image2.png"))
+	assertNoError(t, ac.Set("og:image:alt", "alt2"))
+	assertNoError(t, ac.Set("og:image:alt", "alt3"))
+	assertNoError(t, ac.Set("al:android:url", "example://android1"))
+	assertNoError(t, ac.Set("al:android:url", "example://android2"))
+	assertNoError(t, ac.Set("al:ios:url", "example://ios"))
+	assertNoError(t, ac.Set("al:web", "http://example.com"))
+	assertNoError(t, ac.Set("og:description", "description"))
+	assertNoError(t, ac.Set("fb:app_id", "123"))
+	assertNoError(t, ac.Finish())
+
+	assertEqual(t, v.Title, "title")
+	assertEqual(t, v.Images, []Image{
+		{URL: "http://example.com/image1.png", Width: 100, Extra: map[string]string{"og:image:alt": "alt1"}},
+		{URL: "http://example.com/image2.png", Extra: map[string]string{"og:image:alt": "alt2"}},
+	})
+	assertEqual(t, v.AppLinks, map[string][]string{
+		"al:android:url": {"example://android1", "example://android2"},
+		"al:web":         {"http://example.com"},
+	})
+	// The name of the parent is preferred to the shorter pattern.
+	assertEqual(t, v.IOS, map[string]string{"al:ios:url": "example://ios"})
+	assertEqual(t, v.Others, map[string]string{"og:description": "description", "fb:app_id": "123"})
+}
+
+func Test_StructAccessor_PatternAndStructuredParent(t *testing.T) {
+	var v struct {
+		Images []Image           `googp:"og:image"`
+		Extra  map[string]string `googp:"og:image:*"`
+	}
+
+	ac := newAccessor(nil, reflect.ValueOf(&v))
+	assertNoError(t, ac.Set("og:image", "http://example.com/image.png"))
+	assertNoError(t, ac.Set("og:image:width", "100"))
+	assertNoError(t, ac.Set("og:image:unknown", "value"))
+	assertNoError(t, ac.Finish())
+
+	assertEqual(t, len(v.Images), 1)
+	assertEqual(t, v.Images[0].URL, "http://example.com/image.png")
+	assertEqual(t, v.Images[0].Width, 100)
+	assertEqual(t, v.Extra, map[string]string{"og:image:unknown": "value"})
+}
+
+func Test_StructAccessor_PatternAndUnacceptedParent(t *testing.T) {
+	var v struct {
+		Locale string            `googp:"og:locale"`
+		Images []Image           `googp:"og:image"`
+		Others map[string]string `googp:"*"`
+	}
+
+	ac := newAccessor(nil, reflect.ValueOf(&v))
+	assertNoError(t, ac.Set("og:locale", "ja_JP"))
+	assertNoError(t, ac.Set("og:locale:alternate", "en_US"))
+	assertNoError(t, ac.Set("og:image", "http://example.com/image.png"))
+	assertNoError(t, ac.Set("og:image:user_generated", "true"))
+	assertNoError(t, ac.Finish())
+
+	assertEqual(t, v.Locale, "ja_JP")
+	assertEqual(t, v.Images, []Image{{URL: "http://example.com/image.png"}})
+	assertEqual(t, v.Others, map[string]string{"og:locale:alternate": "en_US", "og:image:user_generated": "true"})
+}
+
+func Test_MapAccessor(t *testing.T) {
+	m := map[string]int{"og:image:width": 1}
+	ac := newAccessor(nil, reflect.ValueOf(m))
+	assertNoError(t, ac.Set("og:image:height", "100"))
+	assertNoError(t, ac.Set("og:image:width", "200"))
+	assertError(t, ac.Set("og:image:depth", "invalid"))
+	assertEqual(t, m, map[string]int{"og:image:width": 200, "og:image:height": 100})
+
+	var nilMap map[string]string
+	assertError(t, newAccessor(nil, reflect.ValueOf(nilMap)).Set("og:title", "title"))
+
+	var intKeys map[int]string
+	assertError(t, newAccessor(nil, reflect.ValueOf(&intKeys)).Set("og:title", "title"))
+}
+
+func TestPatternPrefix(t *testing.T) {
+	prefix, ok := patternPrefix("al:*")
+	assertEqual(t, prefix, "al")
+	assertEqual(t, ok, true)
+
+	prefix, ok = patternPrefix("*")
+	assertEqual(t, prefix, "")
+	assertEqual(t, ok, true)
+
+	_, ok = patternPrefix("al*")
+	assertEqual(t, ok, false)
+	_, ok = patternPrefix("og:image")
+	assertEqual(t, ok, false)
+}
+
+func Test_StructAccessor_RelativeName(t *testing.T) {
+	type Card struct {
+		Site   string            `googp:":site"`
+		Images []Image           `googp:":image"`
+		Extra  map[string]string `googp:":*"`
+		Title  string            `googp:":title,required"`
+		Type   string            `googp:":type,default=summary"`
+	}
+	var v struct {
+		Images  []Image `googp:"og:image"`
+		Twitter Card    `googp:"twitter"`
+		Product *Image  `googp:"product:image"`
+	}
+
+	ac := newAccessor(nil, reflect.ValueOf(&v))
+	assertNoError(t, ac.Set("og:image", "http://example.com/og.png"))
+	assertNoError(t, ac.Set("og:image:width", "100"))
+	assertNoError(t, ac.Set("twitter:site", "@example"))
+	assertNoError(t, ac.Set("twitter:image", "http://example.com/twitter1.png"))
+	assertNoError(t, ac.Set("twitter:image:alt", "alt"))
+	assertNoError(t, ac.Set("twitter:image", "http://example.com/twitter2.png"))
+	assertNoError(t, ac.Set("twitter:image:width", "200"))
+	assertNoError(t, ac.Set("twitter:creator", "@creator"))
+	assertNoError(t, ac.Set("product:image:url", "http://example.com/product.png"))
+	assertNoError(t, ac.Set("product:image:secure_url", "https://example.com/product.png"))
+
+	err := ac.Finish()
+	missingErr, ok := err.(*MissingRequiredError)
+	assertEqual(t, ok, true)
+	assertEqual(t, missingErr.Properties, []string{"twitter:title"})
+
+	assertEqual(t, v.Images, []Image{{URL: "http://example.com/og.png", Width: 100}})
+	assertEqual(t, v.Twitter, Card{
+		Site: "@example",
+		Images: []Image{
+			{URL: "http://example.com/twitter1.png", Alt: "alt"},
+			{URL: "http://example.com/twitter2.png", Width: 200},
+		},
+		Extra: map[string]string{"twitter:creator": "@creator"},
+		Type:  "summary",
+	})
+	assertEqual(t, v.Product, &Image{URL: "http://example.com/product.png", SecureURL: "https://example.com/product.png"})
+
+	// The relative names are ignored without the parent field.
+	var card Card
+	ac = newAccessor(nil, reflect.ValueOf(&card))
+	assertNoError(t, ac.Set("twitter:site", "@example"))
+	assertEqual(t, card.Site, "")
+}
+
+type embeddedArticle struct {
+	Title     string `googp:"article:title"`
+	Author    string `googp:"article:author"`
+	Published string `googp:"article:published_time"`
+}
+
+func Test_StructAccessor_Embedded(t *testing.T) {
+	type Article struct {
+		Author  string `googp:"article:author"`
+		Section string `googp:"article:section"`
+	}
+	type ArticlePage struct {
+		OGP
+		*Article
+		Author string `googp:"og:author"`
+	}
+
+	var v ArticlePage
+	ac := newAccessor(nil, reflect.ValueOf(&v))
+	assertNoError(t, ac.Set("og:title", "title"))
+	assertNoError(t, ac.Set("og:image", "http://example.com/image.png"))
+	assertNoError(t, ac.Set("og:image:width", "100"))
+	assertNoError(t, ac.Set("article:author", "author"))
+	assertNoError(t, ac.Set("og:author", "og author"))
+	assertNoError(t, ac.Finish())
+	assertEqual(t, v.Title, "title")
+	assertEqual(t, v.Images, []Image{{URL: "http://example.com/image.png", Width: 100}})
+	assertEqual(t, v.Article, &Article{Author: "author"})
+	assertEqual(t, v.Author, "og author")
+
+	// The nil pointers of the embedded structs are allocated on demand.
+	v = ArticlePage{}
+	ac = newAccessor(nil, reflect.ValueOf(&v))
+	assertNoError(t, ac.Set("og:title", "title"))
+	assertNoError(t, ac.Finish())
+	assertEqual(t, v.Article, (*Article)(nil))
+}
+
+func Test_StructAccessor_EmbeddedConflict(t *testing.T) {
+	type Inner struct {
+		Title string `googp:"og:title"`
+		Type  string `googp:"og:type"`
+	}
+	type Tagged struct {
+		URL string `googp:"og:url"`
+	}
+	type Inferred struct {
+		URL string
+	}
+	type Video struct {
+		URL   string `googp:":"`
+		Title string `googp:":title"`
+	}
+	var v struct {
+		Inferred
+		Tagged
+		Inner
+		Title string `googp:"og:title"`
+		Video `googp:"og:video"`
+		embeddedArticle
+	}
+
+	ac := newAccessor(nil, reflect.ValueOf(&v))
+	assertNoError(t, ac.Set("og:title", "title"))
+	assertNoError(t, ac.Set("og:type", "website"))
+	assertNoError(t, ac.Set("og:url", "http://example.com"))
+	assertNoError(t, ac.Set("og:video", "video"))
+	assertNoError(t, ac.Set("og:video:title", "video title"))
+	assertNoError(t, ac.Set("article:author", "author"))
+	assertNoError(t, ac.Finish())
+
+	// The shallower field is preferred.
+	assertEqual(t, v.Title, "title")
+	assertEqual(t, v.Inner, Inner{Type: "website"})
+	// The field which has the tag is preferred.
+	assertEqual(t, v.Tagged.URL, "http://example.com")
+	assertEqual(t, v.Inferred.URL, "")
+	// The embedded struct which has the tag is not inlined.
+	assertEqual(t, v.Video, Video{URL: "video", Title: "video title"})
+	// The exported fields of the unexported embedded struct are promoted.
+	assertEqual(t, v.embeddedArticle.Author, "author")
+}
+
+type embeddedTitle1 struct {
+	Title string `googp:"og:title"`
+	Type  string `googp:"og:type"`
+}
+
+type embeddedTitle2 struct {
+	Title string `googp:"og:title"`
+	Type  string
+}
+
+func Test_StructAccessor_EmbeddedAmbiguous(t *testing.T) {
+	var v struct {
+		embeddedTitle1
+		embeddedTitle2
+	}
+
+	ac := newAccessor(nil, reflect.ValueOf(&v))
+	assertNoError(t, ac.Set("og:title", "title"))
+	assertNoError(t, ac.Set("og:type", "website"))
+	assertNoError(t, ac.Finish())
+
+	// Like encoding/json, the ambiguous fields at the same depth are ignored.
+	assertEqual(t, v.embeddedTitle1.Title, "")
+	assertEqual(t, v.embeddedTitle2.Title, "")
+	// The field which has the tag is used, even if they are the same depth.
+	assertEqual(t, v.embeddedTitle1.Type, "website")
+	assertEqual(t, v.embeddedTitle2.Type, "")
+
+	// The ambiguity is reported by CheckModel.
+	var modelErr *ModelError
+	assertEqual(t, errors.As(CheckModel(reflect.TypeOf(v)), &modelErr), true)
+	assertEqual(t, len(modelErr.Problems), 3)
+	assertEqual(t, modelErr.Problems[0].Kind, ModelEmbeddedCollision)
+	assertEqual(t, modelErr.Problems[0].Message, "og:title of embeddedTitle1.Title is never used since it is ambiguous")
+}
@@ -0,0 +1,236 @@
+package googp
+
+import (
+	"context"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	defaultBatchConcurrency     = 8
+	defaultBatchHostConcurrency = 2
+)
+
+// BatchOpts is an option of FetchBatch.
+type BatchOpts struct {
+	// Maximum number of URLs fetched at the same time. Default is 8.
+	Concurrency int
+	// Maximum number of URLs fetched at the same time for each host. Default is 2.
+	HostConcurrency int
+	// Minimum interval between the starts of requests to the same host.
+	// When FetcherOpts.Robots is specified and `Crawl-delay` is longer than it, `Crawl-delay` is used.
+	HostDelay time.Duration
+	// NewValue returns the value which OGP information of the URL is parsed into.
+	// If it is nil, `*OGP` is used.
+	NewValue func(rawurl string) interface{}
+}
+
+// BatchResult is a result of each URL in FetchBatch.
+type BatchResult struct {
+	// URL is the requested URL.
+	URL string
+	// FinalURL is the URL of the content after following the redirects.
+	FinalURL string
+	// Redirects is the chain of redirects in order.
+	Redirects []*Redirect
+	// Value is the value returned by BatchOpts.NewValue, which OGP information is parsed into.
+	Value interface{}
+	// Err is an error occurred while fetching or parsing.
+	Err error
+	// StartedAt is the time when the request is started.
+	StartedAt time.Time
+	// Duration is the time spent fetching and parsing.
+	Duration time.Duration
+}
+
+// batchHost is the state of a host in FetchBatch.
+type batchHost struct {
+	mu sync.Mutex
+	// URLs waiting to be fetched in order.
+	queue []string
+	// Number of workers fetching the URLs in the queue.
+	workers int
+	next    time.Time
+}
+
+// FetchBatch fetches each URL received from the channel, and sends the results in completion order.
+// The returned channel is closed when all URLs are processed or the context is done,
+// so the caller must receive from it until it is closed.
+//
+// The URLs are queued for each host, so that the URLs of a busy host do not block the other hosts.
+func (f *Fetcher) FetchBatch(ctx context.Context, urls <-chan string, opts ...BatchOpts) <-chan *BatchResult {
+	var opt BatchOpts
+	switch len(opts) {
+	case 0:
+	case 1:
+		opt = opts[0]
+	default:
+		panic("Cannot specify multiple BatchOpts")
+	}
+	if opt.Concurrency <= 0 {
+		opt.Concurrency = defaultBatchConcurrency
+	}
+	if opt.HostConcurrency <= 0 {
+		opt.HostConcurrency = defaultBatchHostConcurrency
+	}
+	if opt.NewValue == nil {
+		opt.NewValue = func(string) interface{} { return &OGP{} }
+	}
+
+	results := make(chan *BatchResult, opt.Concurrency)
+	sem := make(chan struct{}, opt.Concurrency)
+	hosts := make(map[string]*batchHost)
+
+	go func() {
+		var wg sync.WaitGroup
+		defer close(results)
+		defer wg.Wait()
+
+		for {
+			var (
+				rawurl string
+				ok     bool
+			)
+			select {
+			case rawurl, ok = <-urls:
+			case <-ctx.Done():
+			}
+			if !ok {
+				return
+			}
+
+			u, err := url.Parse(rawurl)
+			if err != nil {
+				// NOTE: The error is returned from FetchContext.
+				wg.Add(1)
+				go func(rawurl string) {
+					defer wg.Done()
+					results <- f.fetchBatchItem(ctx, &opt, sem, rawurl, nil)
+				}(rawurl)
+				continue
+			}
+
+			key := strings.ToLower(u.Host)
+			host := hosts[key]
+			if host == nil {
+				host = &batchHost{}
+				hosts[key] = host
+			}
+
+			host.mu.Lock()
+			host.queue = append(host.queue, rawurl)
+			start := host.workers < opt.HostConcurrency
+			if start {
+				host.workers++
+			}
+			host.mu.Unlock()
+
+			if start {
+				wg.Add(1)
+				go func(host *batchHost) {
+					defer wg.Done()
+					for {
+						host.mu.Lock()
+						if len(host.queue) == 0 {
+							host.workers--
+							host.mu.Unlock()
+							return
+						}
+						rawurl := host.queue[0]
+						host.queue = host.queue[1:]
+						host.mu.Unlock()
+
+						results <- f.fetchBatchItem(ctx, &opt, sem, rawurl, host)
+					}
+				}(host)
+			}
+		}
+	}()
+
+	return results
+}
+
+// FetchAll is the same as FetchBatch, but it receives the URLs as a slice.
+func (f *Fetcher) FetchAll(ctx context.Context, urls []string, opts ...BatchOpts) <-chan *BatchResult {
+	ch := make(chan string)
+	go func() {
+		defer close(ch)
+		for _, rawurl := range urls {
+			select {
+			case ch <- rawurl:
+			case <-ctx.Done():
+				return
+			}
+		}
+	}()
+	return f.FetchBatch(ctx, ch, opts...)
+}
+
+// fetchBatchItem fetches the URL after the host is ready and a slot of the batch is available.
+// NOTE: The slot is acquired after waiting for the host, so that the workers waiting for a host do not block the other hosts.
+func (f *Fetcher) fetchBatchItem(ctx context.Context, opt *BatchOpts, sem chan struct{}, rawurl string, host *batchHost) *BatchResult {
+	result := &BatchResult{URL: rawurl, Value: opt.NewValue(rawurl)}
+
+	if host != nil {
+		delay := opt.HostDelay
+		// NOTE: The error is returned from FetchContext.
+		if d, err := f.crawlDelay(ctx, rawurl); err == nil && d > delay {
+			delay = d
+		}
+		if err := host.acquire(ctx, sem, delay); err != nil {
+			result.Err = err
+			return result
+		}
+	} else {
+		select {
+		case sem <- struct{}{}:
+		case <-ctx.Done():
+			result.Err = ctx.Err()
+			return result
+		}
+	}
+	defer func() { <-sem }()
+
+	result.StartedAt = time.Now()
+	fetchResult, err := f.Do(ctx, rawurl, result.Value)
+	result.Duration = time.Since(result.StartedAt)
+	result.FinalURL, result.Redirects, result.Err = fetchResult.FinalURL, fetchResult.Redirects, err
+	return result
+}
+
+// acquire blocks until the delay has passed since the previous request to the host, and acquires a slot of sem.
+func (h *batchHost) acquire(ctx context.Context, sem chan struct{}, delay time.Duration) error {
+	for {
+		h.mu.Lock()
+		d := time.Until(h.next)
+		h.mu.Unlock()
+
+		if d > 0 {
+			timer := time.NewTimer(d)
+			select {
+			case <-timer.C:
+			case <-ctx.Done():
+				timer.Stop()
+				return ctx.Err()
+			}
+		}
+
+		select {
+		case sem <- struct{}{}:
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+
+		// NOTE: Another worker of the host may start while waiting for the slot.
+		h.mu.Lock()
+		if now := time.Now(); !now.Before(h.next) {
+			h.next = now.Add(delay)
+			h.mu.Unlock()
+			return nil
+		}
+		h.mu.Unlock()
+		<-sem
+	}
+}
@@ -0,0 +1,169 @@
+package googp
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"sort"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestFetcher_FetchAll(t *testing.T) {
+	server := httptest.NewServer(http.FileServer(http.Dir("data")))
+	defer server.Close()
+
+	urls := []string{
+		server.URL + "/1.html",
+		server.URL + "/2.html",
+		server.URL + "/notfound.html",
+		"://invalid",
+	}
+
+	results := make(map[string]*BatchResult)
+	for result := range NewFetcher().FetchAll(context.Background(), urls) {
+		results[result.URL] = result
+	}
+	assertEqual(t, len(results), 4)
+
+	r := results[server.URL+"/1.html"]
+	assertNoError(t, r.Err)
+	assertEqual(t, r.Value.(*OGP).Title, "title")
+	assertEqual(t, r.StartedAt.IsZero(), false)
+	assertEqual(t, r.FinalURL, server.URL+"/1.html")
+
+	r = results[server.URL+"/2.html"]
+	assertNoError(t, r.Err)
+	assertEqual(t, r.Value.(*OGP).SiteName, "IMDb")
+
+	var statusErr *BadStatusCodeError
+	assertEqual(t, errors.As(results[server.URL+"/notfound.html"].Err, &statusErr), true)
+	assertError(t, results["://invalid"].Err)
+}
+
+func TestFetcher_FetchBatch_NewValue(t *testing.T) {
+	server := httptest.NewServer(http.FileServer(http.Dir("data")))
+	defer server.Close()
+
+	type Title struct {
+		Title string `googp:"og:title"`
+	}
+
+	urls := make(chan string, 2)
+	urls <- server.URL + "/1.html"
+	urls <- server.URL + "/5.html"
+	close(urls)
+
+	var titles []string
+	results := NewFetcher().FetchBatch(context.Background(), urls, BatchOpts{
+		NewValue: func(string) interface{} { return &Title{} },
+	})
+	for result := range results {
+		assertNoError(t, result.Err)
+		titles = append(titles, result.Value.(*Title).Title)
+	}
+	sort.Strings(titles)
+	assertEqual(t, titles, []string{"Open Graph protocol", "title"})
+}
+
+func TestFetcher_FetchBatch_HostConcurrency(t *testing.T) {
+	var (
+		mu      sync.Mutex
+		running int
+		maximum int
+		starts  []time.Time
+	)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		running++
+		if running > maximum {
+			maximum = running
+		}
+		starts = append(starts, time.Now())
+		mu.Unlock()
+
+		time.Sleep(10 * time.Millisecond)
+		http.ServeFile(w, r, "data/1.html")
+
+		mu.Lock()
+		running--
+		mu.Unlock()
+	}))
+	defer server.Close()
+
+	urls := make([]string, 6)
+	for i := range urls {
+		urls[i] = server.URL
+	}
+
+	results := NewFetcher().FetchAll(context.Background(), urls, BatchOpts{
+		Concurrency:     4,
+		HostConcurrency: 1,
+		HostDelay:       20 * time.Millisecond,
+	})
+	var count int
+	for result := range results {
+		assertNoError(t, result.Err)
+		count++
+	}
+	assertEqual(t, count, 6)
+	assertEqual(t, maximum, 1)
+	for i := 1; i < len(starts); i++ {
+		if d := starts[i].Sub(starts[i-1]); d < 15*time.Millisecond {
+			t.Errorf("The requests to the same host are too close (%s)", d)
+		}
+	}
+}
+
+func TestFetcher_FetchBatch_Cancel(t *testing.T) {
+	done := make(chan struct{})
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		select {
+		case <-done:
+		case <-r.Context().Done():
+		}
+	}))
+	defer server.Close()
+	defer close(done)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	urls := make(chan string)
+	results := NewFetcher().FetchBatch(ctx, urls)
+
+	urls <- server.URL
+	cancel()
+
+	var count int
+	for result := range results {
+		assertEqual(t, errors.Is(result.Err, context.Canceled), true)
+		count++
+	}
+	assertEqual(t, count, 1)
+}
+
+func TestFetcher_FetchBatch_HostDelayDoesNotBlockOtherHosts(t *testing.T) {
+	busy := httptest.NewServer(http.FileServer(http.Dir("data")))
+	defer busy.Close()
+	other := httptest.NewServer(http.FileServer(http.Dir("data")))
+	defer other.Close()
+
+	urls := []string{busy.URL + "/1.html", busy.URL + "/1.html", busy.URL + "/1.html", busy.URL + "/1.html", other.URL + "/1.html"}
+	results := NewFetcher().FetchAll(context.Background(), urls, BatchOpts{
+		Concurrency:     2,
+		HostConcurrency: 2,
+		HostDelay:       50 * time.Millisecond,
+	})
+
+	var order []string
+	for result := range results {
+		assertNoError(t, result.Err)
+		order = append(order, result.URL)
+	}
+	assertEqual(t, len(order), 5)
+	// The URL of the other host is fetched while the workers of the busy host are waiting for HostDelay.
+	if order[0] != other.URL+"/1.html" && order[1] != other.URL+"/1.html" {
+		t.Errorf("The other host is blocked by the busy host: %v", order)
+	}
+}
@@ -0,0 +1,59 @@
+package googp
+
+import (
+	"bytes"
+	"io/ioutil"
+	"path/filepath"
+	"testing"
+
+	"golang.org/x/net/html"
+)
+
+func BenchmarkParser_Parse(b *testing.B) {
+	files, err := filepath.Glob("data/*.html")
+	if err != nil {
+		b.Fatal(err)
+	}
+
+	parser := NewParser(ParserOpts{IncludeBody: true})
+	for _, file := range files {
+		data, err := ioutil.ReadFile(file)
+		if err != nil {
+			b.Fatal(err)
+		}
+
+		b.Run(filepath.Base(file), func(b *testing.B) {
+			b.ReportAllocs()
+			for i := 0; i < b.N; i++ {
+				var ogp OGP
+				// NOTE: Some fixtures have invalid values intentionally.
+				_ = parser.Parse(bytes.NewReader(data), &ogp)
+			}
+		})
+	}
+}
+
+func BenchmarkParser_ParseNode(b *testing.B) {
+	data, err := ioutil.ReadFile("data/2.html")
+	if err != nil {
+		b.Fatal(err)
+	}
+	node, err := parseHTML(data)
+	if err != nil {
+		b.Fatal(err)
+	}
+
+	parser := NewParser()
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		var ogp OGP
+		if err := parser.ParseNode(node, &ogp); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
+
+func parseHTML(data []byte) (*html.Node, error) {
+	return html.Parse(bytes.NewReader(data))
+}
@@ -0,0 +1,236 @@
+package googp
+
+import (
+	"container/list"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Cache is a storage of the fetched contents used by Fetcher.
+// The key is a normalized URL with the options used to extract the metas (ParserOpts.IncludeBody and FetcherOpts.FollowMetaRefresh).
+//
+// NOTE: ParserOpts.PreNodeFunc cannot be a part of the key.
+// A Cache must not be shared between Fetchers with different PreNodeFunc, because the metas returned by it are cached.
+type Cache interface {
+	// Get returns the entry of the key. It returns false when the entry is not found.
+	Get(key string) (*CacheEntry, bool)
+	// Set stores the entry of the key.
+	Set(key string, entry *CacheEntry)
+}
+
+// CacheEntry is an entry of Cache.
+// It has the raw metas of the page, so it can be used for any type that OGP information is parsed into.
+type CacheEntry struct {
+	Metas []*Meta `json:"metas"`
+	// FinalURL is the URL of the content after following the redirects.
+	FinalURL string `json:"final_url,omitempty"`
+	// Redirects is the chain of redirects followed to get the content.
+	Redirects []*Redirect `json:"redirects,omitempty"`
+	// Refresh is the URL of `<meta http-equiv="refresh">`.
+	Refresh string `json:"refresh,omitempty"`
+	// RefreshDelay is the delay of `<meta http-equiv="refresh">`.
+	RefreshDelay time.Duration `json:"refresh_delay,omitempty"`
+	// ETag is used for revalidation with If-None-Match.
+	ETag string `json:"etag,omitempty"`
+	// LastModified is used for revalidation with If-Modified-Since.
+	LastModified string `json:"last_modified,omitempty"`
+	// Expires is the time until the entry can be used without revalidation.
+	Expires time.Time `json:"expires"`
+}
+
+// MemoryCache is an in-memory Cache that evicts the least recently used entry.
+type MemoryCache struct {
+	capacity int
+	mu       sync.Mutex
+	ll       *list.List
+	items    map[string]*list.Element
+}
+
+type memoryCacheItem struct {
+	key   string
+	entry *CacheEntry
+}
+
+// FileCache is a Cache that stores each entry as a JSON file in the directory.
+// Errors while reading or writing the files are treated as cache misses.
+type FileCache struct {
+	dir string
+}
+
+// NewMemoryCache create a `MemoryCache` which has up to capacity entries.
+func NewMemoryCache(capacity int) *MemoryCache {
+	if capacity <= 0 {
+		panic("capacity must be greater than 0")
+	}
+	return &MemoryCache{
+		capacity: capacity,
+		ll:       list.New(),
+		items:    make(map[string]*list.Element),
+	}
+}
+
+// Get returns the entry of the key. It returns false when the entry is not found.
+func (c *MemoryCache) Get(key string) (*CacheEntry, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	elem, ok := c.items[key]
+	if !ok {
+		return nil, false
+	}
+	c.ll.MoveToFront(elem)
+	return elem.Value.(*memoryCacheItem).entry, true
+}
+
+// Set stores the entry of the key.
+func (c *MemoryCache) Set(key string, entry *CacheEntry) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if elem, ok := c.items[key]; ok {
+		elem.Value.(*memoryCacheItem).entry = entry
+		c.ll.MoveToFront(elem)
+		return
+	}
+
+	c.items[key] = c.ll.PushFront(&memoryCacheItem{key: key, entry: entry})
+	for c.ll.Len() > c.capacity {
+		elem := c.ll.Back()
+		c.ll.Remove(elem)
+		delete(c.items, elem.Value.(*memoryCacheItem).key)
+	}
+}
+
+// Len returns the number of entries.
+func (c *MemoryCache) Len() int {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.ll.Len()
+}
+
+// NewFileCache create a `FileCache` which stores the entries in the directory.
+func NewFileCache(dir string) *FileCache {
+	return &FileCache{dir: dir}
+}
+
+// Get returns the entry of the key. It returns false when the entry is not found.
+func (c *FileCache) Get(key string) (*CacheEntry, bool) {
+	data, err := ioutil.ReadFile(c.path(key))
+	if err != nil {
+		return nil, false
+	}
+
+	var entry CacheEntry
+	if err := json.Unmarshal(data, &entry); err != nil {
+		return nil, false
+	}
+	return &entry, true
+}
+
+// Set stores the entry of the key.
+func (c *FileCache) Set(key string, entry *CacheEntry) {
+	data, err := json.Marshal(entry)
+	if err != nil {
+		return
+	}
+	if err := os.MkdirAll(c.dir, 0755); err != nil {
+		return
+	}
+
+	// NOTE: Write to a temporary file and rename it, so that readers never see a partial file.
+	f, err := ioutil.TempFile(c.dir, ".tmp-")
+	if err != nil {
+		return
+	}
+	_, err = f.Write(data)
+	if cerr := f.Close(); err == nil {
+		err = cerr
+	}
+	if err != nil {
+		os.Remove(f.Name())
+		return
+	}
+	if err := os.Rename(f.Name(), c.path(key)); err != nil {
+		os.Remove(f.Name())
+	}
+}
+
+func (c *FileCache) path(key string) string {
+	sum := sha256.Sum256([]byte(key))
+	return filepath.Join(c.dir, hex.EncodeToString(sum[:])+".json")
+}
+
+// normalizeURL returns the URL used as the key of Cache.
+func normalizeURL(rawurl string) string {
+	u, err := url.Parse(rawurl)
+	if err != nil {
+		return rawurl
+	}
+
+	u.Scheme = strings.ToLower(u.Scheme)
+	host := strings.ToLower(u.Hostname())
+	port := u.Port()
+	if (u.Scheme == "http" && port == "80") || (u.Scheme == "https" && port == "443") {
+		port = ""
+	}
+	if strings.Contains(host, ":") {
+		host = "[" + host + "]"
+	}
+	if port != "" {
+		host += ":" + port
+	}
+	u.Host = host
+
+	if u.Path == "" {
+		u.Path = "/"
+	}
+	if u.RawQuery != "" {
+		u.RawQuery = u.Query().Encode()
+	}
+	u.Fragment = ""
+	return u.String()
+}
+
+// cacheExpires returns the time until the response can be used without revalidation,
+// and whether the response can be stored.
+func cacheExpires(header http.Header, now time.Time) (time.Time, bool) {
+	var maxAge *int
+	for _, directive := range strings.Split(header.Get("Cache-Control"), ",") {
+		name, value := strings.TrimSpace(directive), ""
+		if i := strings.Index(name, "="); i >= 0 {
+			name, value = strings.TrimSpace(name[:i]), strings.Trim(strings.TrimSpace(name[i+1:]), `"`)
+		}
+
+		switch strings.ToLower(name) {
+		case "no-store":
+			return time.Time{}, false
+		case "no-cache":
+			zero := 0
+			maxAge = &zero
+		case "max-age":
+			if n, err := strconv.Atoi(value); err == nil && maxAge == nil {
+				maxAge = &n
+			}
+		}
+	}
+
+	if maxAge != nil {
+		return now.Add(time.Duration(*maxAge) * time.Second), true
+	}
+	if expires := header.Get("Expires"); expires != "" {
+		// NOTE: Invalid values (e.g. "0") mean already expired.
+		t, _ := http.ParseTime(expires)
+		return t, true
+	}
+	return time.Time{}, true
+}
@@ -0,0 +1,243 @@
+package googp
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+func TestMemoryCache(t *testing.T) {
+	cache := NewMemoryCache(2)
+	cache.Set("a", &CacheEntry{ETag: "a"})
+	cache.Set("b", &CacheEntry{ETag: "b"})
+
+	entry, ok := cache.Get("a")
+	assertEqual(t, ok, true)
+	assertEqual(t, entry.ETag, "a")
+
+	// b is the least recently used.
+	cache.Set("c", &CacheEntry{ETag: "c"})
+	assertEqual(t, cache.Len(), 2)
+	_, ok = cache.Get("b")
+	assertEqual(t, ok, false)
+	_, ok = cache.Get("a")
+	assertEqual(t, ok, true)
+
+	cache.Set("c", &CacheEntry{ETag: "c2"})
+	entry, _ = cache.Get("c")
+	assertEqual(t, entry.ETag, "c2")
+	assertEqual(t, cache.Len(), 2)
+}
+
+func TestFileCache(t *testing.T) {
+	cache := NewFileCache(t.TempDir() + "/cache")
+	_, ok := cache.Get("http://example.com/")
+	assertEqual(t, ok, false)
+
+	expires := time.Date(2020, 5, 20, 1, 1, 25, 0, time.UTC)
+	cache.Set("http://example.com/", &CacheEntry{
+		Metas:   []*Meta{{Property: "og:title", Content: "title"}},
+		ETag:    `"etag"`,
+		Expires: expires,
+	})
+
+	entry, ok := cache.Get("http://example.com/")
+	assertEqual(t, ok, true)
+	assertEqual(t, entry.Metas, []*Meta{{Property: "og:title", Content: "title"}})
+	assertEqual(t, entry.ETag, `"etag"`)
+	assertEqual(t, entry.Expires.Equal(expires), true)
+
+	_, ok = cache.Get("http://example.com/other")
+	assertEqual(t, ok, false)
+}
+
+func TestFetcher_Fetch_Cache(t *testing.T) {
+	var count int
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		count++
+		w.Header().Set("Cache-Control", "max-age=60")
+		http.ServeFile(w, r, "data/1.html")
+	}))
+	defer server.Close()
+
+	fetcher := NewFetcher(FetcherOpts{Cache: NewMemoryCache(10)})
+	var ogp1 OGP
+	assertNoError(t, fetcher.Fetch(server.URL+"/#fragment", &ogp1))
+	assertEqual(t, ogp1.Title, "title")
+
+	type Title struct {
+		Title string `googp:"og:title"`
+	}
+	var ogp2 Title
+	assertNoError(t, fetcher.Fetch(server.URL, &ogp2))
+	assertEqual(t, ogp2.Title, "title")
+	assertEqual(t, count, 1)
+}
+
+func TestFetcher_Fetch_CacheRevalidate(t *testing.T) {
+	var (
+		count       int
+		notModified int
+	)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		count++
+		w.Header().Set("Cache-Control", "no-cache")
+		w.Header().Set("ETag", `"v1"`)
+		if r.Header.Get("If-None-Match") == `"v1"` {
+			notModified++
+			w.WriteHeader(http.StatusNotModified)
+			return
+		}
+		http.ServeFile(w, r, "data/1.html")
+	}))
+	defer server.Close()
+
+	fetcher := NewFetcher(FetcherOpts{Cache: NewFileCache(t.TempDir())})
+	for i := 0; i < 3; i++ {
+		var ogp OGP
+		assertNoError(t, fetcher.Fetch(server.URL, &ogp))
+		assertEqual(t, ogp.Title, "title")
+	}
+	assertEqual(t, count, 3)
+	assertEqual(t, notModified, 2)
+}
+
+func TestFetcher_Fetch_CacheLastModified(t *testing.T) {
+	lastModified := time.Date(2020, 5, 20, 1, 1, 25, 0, time.UTC)
+	var notModified int
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Expires", "0")
+		if r.Header.Get("If-Modified-Since") != "" {
+			notModified++
+		}
+		http.ServeContent(w, r, "1.html", lastModified, mustOpen(t, "data/1.html"))
+	}))
+	defer server.Close()
+
+	fetcher := NewFetcher(FetcherOpts{Cache: NewMemoryCache(10)})
+	for i := 0; i < 2; i++ {
+		var ogp OGP
+		assertNoError(t, fetcher.Fetch(server.URL, &ogp))
+		assertEqual(t, ogp.Title, "title")
+	}
+	assertEqual(t, notModified, 1)
+}
+
+func TestFetcher_Fetch_CacheNoStore(t *testing.T) {
+	var count int
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		count++
+		w.Header().Set("Cache-Control", "no-store")
+		w.Header().Set("ETag", `"v1"`)
+		http.ServeFile(w, r, "data/1.html")
+	}))
+	defer server.Close()
+
+	cache := NewMemoryCache(10)
+	fetcher := NewFetcher(FetcherOpts{Cache: cache})
+	for i := 0; i < 2; i++ {
+		var ogp OGP
+		assertNoError(t, fetcher.Fetch(server.URL, &ogp))
+		assertEqual(t, ogp.Title, "title")
+	}
+	assertEqual(t, count, 2)
+	assertEqual(t, cache.Len(), 0)
+}
+
+func TestFetcher_Do_CacheRedirects(t *testing.T) {
+	for _, cacheControl := range []string{"max-age=60", "no-cache"} {
+		var count int
+		mux := http.NewServeMux()
+		mux.Handle("/short", http.RedirectHandler("/long", http.StatusMovedPermanently))
+		mux.HandleFunc("/long", func(w http.ResponseWriter, r *http.Request) {
+			count++
+			w.Header().Set("Cache-Control", cacheControl)
+			w.Header().Set("ETag", `"v1"`)
+			if r.Header.Get("If-None-Match") == `"v1"` {
+				w.WriteHeader(http.StatusNotModified)
+				return
+			}
+			http.ServeFile(w, r, "data/1.html")
+		})
+		server := httptest.NewServer(mux)
+		defer server.Close()
+
+		fetcher := NewFetcher(FetcherOpts{Cache: NewFileCache(t.TempDir())})
+		for i := 0; i < 2; i++ {
+			var ogp OGP
+			result, err := fetcher.Do(context.Background(), server.URL+"/short", &ogp)
+			assertNoError(t, err)
+			assertEqual(t, ogp.Title, "title")
+			assertEqual(t, result.FinalURL, server.URL+"/long")
+			assertEqual(t, result.Redirects, []*Redirect{{URL: server.URL + "/short", StatusCode: http.StatusMovedPermanently}})
+		}
+		if cacheControl == "no-cache" {
+			assertEqual(t, count, 2)
+		} else {
+			assertEqual(t, count, 1)
+		}
+	}
+}
+
+func TestFetcher_Fetch_CacheSharedOpts(t *testing.T) {
+	var count int
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		count++
+		w.Header().Set("Cache-Control", "max-age=60")
+		w.Write([]byte(`<html><head><meta property="og:title" content="head"></head>` +
+			`<body><meta property="og:description" content="body"></body></html>`))
+	}))
+	defer server.Close()
+
+	cache := NewMemoryCache(10)
+	var ogp OGP
+	assertNoError(t, NewFetcher(FetcherOpts{Cache: cache}).Fetch(server.URL, &ogp))
+	assertEqual(t, ogp.Description, "")
+
+	// The metas cached by the Fetcher with the different options are not used.
+	fetcher := NewFetcher(FetcherOpts{Cache: cache, ParserOpts: ParserOpts{IncludeBody: true}})
+	ogp = OGP{}
+	assertNoError(t, fetcher.Fetch(server.URL, &ogp))
+	assertEqual(t, ogp.Description, "body")
+	ogp = OGP{}
+	assertNoError(t, fetcher.Fetch(server.URL, &ogp))
+	assertEqual(t, ogp.Description, "body")
+	assertEqual(t, count, 2)
+}
+
+func Test_NormalizeURL(t *testing.T) {
+	assertEqual(t, normalizeURL("HTTP://Example.COM"), "http://example.com/")
+	assertEqual(t, normalizeURL("http://example.com:80/a?b=2&a=1#top"), "http://example.com/a?a=1&b=2")
+	assertEqual(t, normalizeURL("https://example.com:443/"), "https://example.com/")
+	assertEqual(t, normalizeURL("https://example.com:8443/"), "https://example.com:8443/")
+	assertEqual(t, normalizeURL("http://[::1]:80/"), "http://[::1]/")
+}
+
+func Test_CacheExpires(t *testing.T) {
+	now := time.Date(2020, 5, 20, 1, 1, 25, 0, time.UTC)
+
+	expires, ok := cacheExpires(http.Header{"Cache-Control": {"public, max-age=60"}}, now)
+	assertEqual(t, ok, true)
+	assertEqual(t, expires, now.Add(time.Minute))
+
+	expires, ok = cacheExpires(http.Header{"Cache-Control": {"max-age=60, no-cache"}}, now)
+	assertEqual(t, ok, true)
+	assertEqual(t, expires, now)
+
+	_, ok = cacheExpires(http.Header{"Cache-Control": {"no-store"}}, now)
+	assertEqual(t, ok, false)
+
+	expires, ok = cacheExpires(http.Header{"Expires": {"Wed, 20 May 2020 02:01:25 GMT"}}, now)
+	assertEqual(t, ok, true)
+	assertEqual(t, expires.Equal(now.Add(time.Hour)), true)
+
+	// Cache-Control has priority over Expires.
+	expires, _ = cacheExpires(http.Header{"Cache-Control": {"max-age=1"}, "Expires": {"Wed, 20 May 2020 02:01:25 GMT"}}, now)
+	assertEqual(t, expires, now.Add(time.Second))
+
+	expires, ok = cacheExpires(http.Header{}, now)
+	assertEqual(t, ok, true)
+	assertEqual(t, expires.IsZero(), true)
+}
@@ -0,0 +1,29 @@
+package googp
+
+// ConflictPolicy is a policy when a property appears more than once for a single value.
+type ConflictPolicy int
+
+const (
+	// ConflictFirst keeps the first value, and ignores later values.
+	ConflictFirst ConflictPolicy = iota
+	// ConflictLast overwrites the value by later values.
+	ConflictLast
+	// ConflictFail returns ConflictError when later values are different from the first value.
+	ConflictFail
+	// ConflictCollect keeps the first value, and collects later values into the field which has `alternates` option.
+	// If the field does not exist, it is same as ConflictFirst.
+	ConflictCollect
+)
+
+var conflictPolicyNames = map[string]ConflictPolicy{
+	"first":   ConflictFirst,
+	"last":    ConflictLast,
+	"error":   ConflictFail,
+	"collect": ConflictCollect,
+}
+
+// parseConflictPolicy returns the policy of the name used in `conflict=${name}` option.
+func parseConflictPolicy(name string) (ConflictPolicy, bool) {
+	policy, ok := conflictPolicyNames[name]
+	return policy, ok
+}
@@ -0,0 +1,123 @@
+package googp
+
+import (
+	"errors"
+	"strings"
+	"testing"
+)
+
+const conflictHTML = `
+<html><head>
+	<meta property="og:title" content="default title" />
+	<meta property="og:title" content="default title" />
+	<meta property="og:title" content="specific title" />
+	<meta property="og:type" content="website" />
+	<meta property="og:type" content="article" />
+</head></html>`
+
+func TestParser_Parse_ConflictPolicy(t *testing.T) {
+	type Model struct {
+		Title string `googp:"og:title"`
+		Type  string `googp:"og:type"`
+	}
+
+	var diags []*Diagnostic
+	parser := NewParser(ParserOpts{OnDiagnostic: func(d *Diagnostic) { diags = append(diags, d) }})
+	var v Model
+	assertNoError(t, parser.Parse(strings.NewReader(conflictHTML), &v))
+	assertEqual(t, v, Model{Title: "default title", Type: "website"})
+	assertEqual(t, diags, []*Diagnostic{
+		{Kind: DiagnosticConflict, Property: "og:title", Value: "specific title"},
+		{Kind: DiagnosticConflict, Property: "og:type", Value: "article"},
+	})
+	assertEqual(t, diags[0].String(), "og:title appeared more than once (value = specific title)")
+
+	diags = nil
+	parser = NewParser(ParserOpts{
+		ConflictPolicy: ConflictLast,
+		OnDiagnostic:   func(d *Diagnostic) { diags = append(diags, d) },
+	})
+	v = Model{}
+	assertNoError(t, parser.Parse(strings.NewReader(conflictHTML), &v))
+	assertEqual(t, v, Model{Title: "specific title", Type: "article"})
+	assertEqual(t, diags, []*Diagnostic{
+		{Kind: DiagnosticConflict, Property: "og:title", Value: "default title"},
+		{Kind: DiagnosticConflict, Property: "og:type", Value: "website"},
+	})
+
+	parser = NewParser(ParserOpts{ConflictPolicy: ConflictFail})
+	v = Model{}
+	err := parser.Parse(strings.NewReader(conflictHTML), &v)
+	var conflictErr *ConflictError
+	assertEqual(t, errors.As(err, &conflictErr), true)
+	assertEqual(t, conflictErr.Property, "og:title")
+	assertEqual(t, conflictErr.Values, []string{"default title", "specific title"})
+	assertEqual(t, err.Error(), "og:title has conflicting values (default title, specific title)")
+}
+
+func TestParser_Parse_ConflictPolicy_Field(t *testing.T) {
+	type Model struct {
+		Title      string   `googp:"og:title,conflict=collect"`
+		OtherTitle []string `googp:"og:title,alternates"`
+		Type       string   `googp:"og:type,conflict=last"`
+	}
+
+	parser := NewParser(ParserOpts{ConflictPolicy: ConflictFail})
+	var v Model
+	assertNoError(t, parser.Parse(strings.NewReader(conflictHTML), &v))
+	assertEqual(t, v, Model{Title: "default title", OtherTitle: []string{"specific title"}, Type: "article"})
+
+	type Model2 struct {
+		Title string `googp:"og:title,conflict=collect"`
+		Type  string `googp:"og:type,conflict=error"`
+	}
+	var v2 Model2
+	assertError(t, parser.Parse(strings.NewReader(conflictHTML), &v2))
+	assertEqual(t, v2.Title, "default title")
+}
+
+func TestParser_Parse_ConflictPolicy_Strict(t *testing.T) {
+	type Model struct {
+		Title string `googp:"og:title"`
+	}
+
+	parser := NewParser(ParserOpts{Strict: true})
+	var v Model
+	err := parser.Parse(strings.NewReader(conflictHTML), &v)
+	var diagErr *DiagnosticError
+	assertEqual(t, errors.As(err, &diagErr), true)
+	assertEqual(t, diagErr.Diagnostic, &Diagnostic{Kind: DiagnosticConflict, Property: "og:title", Value: "specific title"})
+}
+
+func TestParser_Parse_ConflictPolicy_UnknownSubProperty(t *testing.T) {
+	const html = `
+<html><head>
+	<meta property="og:title" content="title" />
+	<meta property="og:type" content="website" />
+	<meta property="og:url" content="http://example.com" />
+	<meta property="og:locale" content="ja_JP" />
+	<meta property="og:locale:alternate" content="en_US" />
+	<meta property="og:image" content="http://example.com/image.png" />
+	<meta property="og:image:user_generated" content="true" />
+	<meta property="og:image:foo" content="bar" />
+</head></html>`
+
+	parser := NewParser(ParserOpts{ConflictPolicy: ConflictLast})
+	var ogp OGP
+	assertNoError(t, parser.Parse(strings.NewReader(html), &ogp))
+	assertEqual(t, ogp.Locale, "ja_JP")
+	assertEqual(t, ogp.Images, []Image{{URL: "http://example.com/image.png"}})
+
+	type Model struct {
+		Locale string `googp:"og:locale"`
+	}
+	parser = NewParser(ParserOpts{ConflictPolicy: ConflictFail})
+	var v Model
+	assertNoError(t, parser.Parse(strings.NewReader(html), &v))
+	assertEqual(t, v, Model{Locale: "ja_JP"})
+
+	parser = NewParser(ParserOpts{Strict: true})
+	ogp = OGP{}
+	assertNoError(t, parser.Parse(strings.NewReader(html), &ogp))
+	assertEqual(t, ogp.Images, []Image{{URL: "http://example.com/image.png"}})
+}
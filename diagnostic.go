@@ -0,0 +1,45 @@
+package googp
+
+import (
+	"fmt"
+)
+
+// DiagnosticKind is a kind of Diagnostic.
+type DiagnosticKind int
+
+const (
+	// DiagnosticConflict means that the property appeared more than once for a single value.
+	DiagnosticConflict DiagnosticKind = iota + 1
+	// DiagnosticOverflow means that the value was dropped because the array or the slice is full.
+	DiagnosticOverflow
+)
+
+// Diagnostic is a problem found while parsing, which is not an error.
+// It is reported to ParserOpts.OnDiagnostic, and it is returned as DiagnosticError in strict mode.
+type Diagnostic struct {
+	Kind DiagnosticKind
+	// Property is the name of the property. (e.g. `og:title`)
+	Property string
+	// Value is the value of the property which caused the problem.
+	Value string
+}
+
+func (d *Diagnostic) String() string {
+	switch d.Kind {
+	case DiagnosticConflict:
+		return fmt.Sprintf("%s appeared more than once (value = %s)", d.Property, d.Value)
+	case DiagnosticOverflow:
+		return fmt.Sprintf("%s was dropped because it exceeds the capacity (value = %s)", d.Property, d.Value)
+	default:
+		return fmt.Sprintf("%s has a problem (value = %s)", d.Property, d.Value)
+	}
+}
+
+// DiagnosticError is an error returned when a diagnostic is reported in strict mode.
+type DiagnosticError struct {
+	Diagnostic *Diagnostic
+}
+
+func (err *DiagnosticError) Error() string {
+	return err.Diagnostic.String()
+}
@@ -0,0 +1,216 @@
+package googp
+
+import (
+	"reflect"
+)
+
+var (
+	documentType = reflect.TypeOf(Document{})
+	// groupingPlan is the plan used to group the properties of Document.
+	groupingPlan = cachedStructPlan(reflect.TypeOf(OGP{}), defaultTagRules)
+)
+
+// Document is a schemaless model of OGP, which can be used instead of a struct.
+//
+// The properties are grouped in the same way as parsing into `googp.OGP`.
+// The structured properties of `og:image`, `og:video` and `og:audio` (e.g. `og:image:width`)
+// belong to the latest element of the parent property, and the other properties are not grouped.
+// (e.g. `og:locale:alternate` is not a structured property of `og:locale`)
+// The structured properties which appear before the parent property are not grouped too.
+//
+//	var doc googp.Document
+//	googp.Fetch(url, &doc)
+//	for _, image := range doc.All("og:image") {
+//	    fmt.Println(image.Value, image.Get("og:image:width"))
+//	}
+type Document struct {
+	metas []*Meta
+	root  Property
+	// The latest top-level property of each name.
+	latest map[string]*Property
+}
+
+// Property is a property of Document, which has the structured properties.
+type Property struct {
+	// Name is the name of the property. (e.g. `og:image`)
+	Name  string
+	Value string
+	// Properties is the structured properties in order of appearance. (e.g. `og:image:width` of `og:image`)
+	Properties []*Property
+}
+
+// documentAccessor is an accessor for writing the values of ogp to a Document.
+type documentAccessor struct {
+	doc *Document
+}
+
+// NewDocument create a `*Document` from the metas.
+func NewDocument(metas []*Meta) *Document {
+	doc := &Document{}
+	for _, meta := range metas {
+		doc.add(meta.Property, meta.Content)
+	}
+	return doc
+}
+
+// Metas returns the metas in order of appearance.
+func (doc *Document) Metas() []*Meta {
+	return doc.metas
+}
+
+// Properties returns the top-level properties in order of appearance.
+func (doc *Document) Properties() []*Property {
+	return doc.root.Properties
+}
+
+// Get returns the value of the first property which has the name. If it does not exist, it returns an empty string.
+func (doc *Document) Get(name string) string {
+	return doc.root.Get(name)
+}
+
+// Lookup returns the value of the first property which has the name, and whether it exists.
+func (doc *Document) Lookup(name string) (string, bool) {
+	return doc.root.Lookup(name)
+}
+
+// All returns all properties which have the name in order of appearance, including the structured properties.
+func (doc *Document) All(name string) []*Property {
+	return doc.root.All(name)
+}
+
+// Map returns the properties as a map of the names.
+// The value is a string for a single property, `[]interface{}` for the repeated properties,
+// and `map[string]interface{}` for a property which has the structured properties.
+//
+//	{"og:title": "title", "og:image": [{"og:image": "url1", "og:image:width": "100"}, "url2"]}
+func (doc *Document) Map() map[string]interface{} {
+	return propertiesMap(doc.root.Properties)
+}
+
+// Decode writes the properties to the value in the same way as Parser.Parse.
+func (doc *Document) Decode(i interface{}, opts ...ParserOpts) error {
+	return NewParser(opts...).setMetas(doc.metas, i)
+}
+
+// Get returns the value of the first structured property which has the name.
+// If it does not exist, it returns an empty string.
+func (p *Property) Get(name string) string {
+	val, _ := p.Lookup(name)
+	return val
+}
+
+// Lookup returns the value of the first structured property which has the name, and whether it exists.
+func (p *Property) Lookup(name string) (string, bool) {
+	var val string
+	found := false
+	p.walk(func(child *Property) bool {
+		if child.Name == name {
+			val, found = child.Value, true
+		}
+		return !found
+	})
+	return val, found
+}
+
+// All returns all structured properties which have the name in order of appearance.
+func (p *Property) All(name string) []*Property {
+	var props []*Property
+	p.walk(func(child *Property) bool {
+		if child.Name == name {
+			props = append(props, child)
+		}
+		return true
+	})
+	return props
+}
+
+// Decode writes the property and the structured properties to the value in the same way as Parser.Parse.
+// (e.g. `og:image` property to `googp.Image`)
+func (p *Property) Decode(i interface{}, opts ...ParserOpts) error {
+	var metas []*Meta
+	if p.Name != "" {
+		metas = append(metas, &Meta{Property: p.Name, Content: p.Value})
+	}
+	p.walk(func(child *Property) bool {
+		metas = append(metas, &Meta{Property: child.Name, Content: child.Value})
+		return true
+	})
+	return NewParser(opts...).setMetas(metas, i)
+}
+
+// Interface returns the value of the property in the same way as Document.Map.
+func (p *Property) Interface() interface{} {
+	if len(p.Properties) == 0 {
+		return p.Value
+	}
+	m := propertiesMap(p.Properties)
+	m[p.Name] = p.Value
+	return m
+}
+
+func propertiesMap(props []*Property) map[string]interface{} {
+	m := make(map[string]interface{})
+	for _, p := range props {
+		v := p.Interface()
+		switch current := m[p.Name].(type) {
+		case nil:
+			m[p.Name] = v
+		case []interface{}:
+			m[p.Name] = append(current, v)
+		default:
+			m[p.Name] = []interface{}{current, v}
+		}
+	}
+	return m
+}
+
+// walk calls the f for each structured property in order of appearance until the f returns false.
+func (p *Property) walk(f func(*Property) bool) bool {
+	for _, child := range p.Properties {
+		if !f(child) || !child.walk(f) {
+			return false
+		}
+	}
+	return true
+}
+
+func (doc *Document) add(key string, val string) {
+	doc.metas = append(doc.metas, &Meta{Property: key, Content: val})
+
+	p := &Property{Name: key, Value: val}
+	parent := &doc.root
+	if name, ok := structuredParent(key); ok && doc.latest[name] != nil {
+		parent = doc.latest[name]
+	}
+	parent.Properties = append(parent.Properties, p)
+
+	if parent == &doc.root {
+		if doc.latest == nil {
+			doc.latest = make(map[string]*Property)
+		}
+		doc.latest[key] = p
+	}
+}
+
+// structuredParent returns the name of the parent property when the key is a structured property.
+// (e.g. `og:image` for `og:image:width`)
+//
+// NOTE: It uses the same rules as parsing into `googp.OGP`, so that the grouping of Document is same as the struct.
+// The key belongs to the field of the array (e.g. `Images`), and it is not a name of the field which starts a new element.
+func structuredParent(key string) (string, bool) {
+	ac := &structAccessor{decoder: defaultDecoder, plan: groupingPlan}
+	f, _ := ac.route(key, nil)
+	if f == nil || f.tag.isContainsName(key) || defaultDecoder.typeKind(f.typ) != arrayKind {
+		return "", false
+	}
+	return rootName(f.tag), true
+}
+
+func (ac *documentAccessor) Set(key string, val string) error {
+	ac.doc.add(key, val)
+	return nil
+}
+
+func (ac *documentAccessor) Finish() error {
+	return nil
+}
@@ -0,0 +1,121 @@
+package googp
+
+import (
+	"strings"
+	"testing"
+)
+
+const documentHTML = `
+<html><head>
+	<meta property="og:title" content="title" />
+	<meta property="og:image" content="http://example.com/image1.png" />
+	<meta property="og:image:width" content="100" />
+	<meta property="og:image:height" content="200" />
+	<meta property="og:image" content="http://example.com/image2.png" />
+	<meta property="og:image:url" content="http://example.com/image3.png" />
+	<meta property="og:image:width" content="300" />
+	<meta property="og:locale" content="en_US" />
+	<meta property="og:locale:alternate" content="ja_JP" />
+	<meta property="og:locale:alternate" content="fr_FR" />
+</head></html>`
+
+func TestDocument(t *testing.T) {
+	var doc Document
+	assertNoError(t, NewParser().Parse(strings.NewReader(documentHTML), &doc))
+
+	assertEqual(t, len(doc.Metas()), 10)
+	assertEqual(t, doc.Get("og:title"), "title")
+	assertEqual(t, doc.Get("og:image:width"), "100")
+	assertEqual(t, doc.Get("og:description"), "")
+	_, ok := doc.Lookup("og:description")
+	assertEqual(t, ok, false)
+
+	var names []string
+	for _, p := range doc.Properties() {
+		names = append(names, p.Name)
+	}
+	assertEqual(t, names, []string{"og:title", "og:image", "og:image", "og:locale", "og:locale:alternate", "og:locale:alternate"})
+
+	images := doc.All("og:image")
+	assertEqual(t, len(images), 2)
+	assertEqual(t, images[0].Value, "http://example.com/image1.png")
+	assertEqual(t, images[0].Get("og:image:width"), "100")
+	assertEqual(t, images[0].Get("og:image:height"), "200")
+	assertEqual(t, images[1].Value, "http://example.com/image2.png")
+	assertEqual(t, images[1].Get("og:image:url"), "http://example.com/image3.png")
+	assertEqual(t, images[1].Get("og:image:width"), "300")
+
+	var alternates []string
+	for _, p := range doc.All("og:locale:alternate") {
+		alternates = append(alternates, p.Value)
+	}
+	assertEqual(t, alternates, []string{"ja_JP", "fr_FR"})
+
+	var image Image
+	assertNoError(t, images[0].Decode(&image))
+	assertEqual(t, image, Image{URL: "http://example.com/image1.png", Width: 100, Height: 200})
+}
+
+func TestDocument_Decode(t *testing.T) {
+	// The grouping of Document is same as the one of the struct.
+	var ogp OGP
+	assertNoError(t, NewParser().Parse(strings.NewReader(documentHTML), &ogp))
+
+	doc := NewDocument(parseDocumentMetas(t, documentHTML))
+	var decoded OGP
+	assertNoError(t, doc.Decode(&decoded))
+	assertEqual(t, decoded, ogp)
+
+	images := doc.All("og:image")
+	assertEqual(t, len(images), len(ogp.Images))
+	for i, p := range images {
+		var image Image
+		assertNoError(t, p.Decode(&image))
+		assertEqual(t, image, ogp.Images[i])
+	}
+}
+
+func TestDocument_NestedProperties(t *testing.T) {
+	doc := NewDocument([]*Meta{
+		{Property: "og:image:width", Content: "100"},
+		{Property: "og:image", Content: "http://example.com/image.png"},
+		{Property: "og:image:width", Content: "200"},
+		{Property: "og:image:width:unit", Content: "px"},
+		{Property: "og:image:width", Content: "300"},
+		{Property: "al:ios", Content: "ios"},
+		{Property: "al:ios:url", Content: "example://ios"},
+	})
+
+	// NOTE: The structured properties before the parent, and the properties which are not modeled by OGP are not grouped.
+	var names []string
+	for _, p := range doc.Properties() {
+		names = append(names, p.Name)
+	}
+	assertEqual(t, names, []string{"og:image:width", "og:image", "al:ios", "al:ios:url"})
+
+	image := doc.All("og:image")[0]
+	assertEqual(t, len(image.Properties), 3)
+	assertEqual(t, image.Get("og:image:width"), "200")
+	assertEqual(t, image.Get("og:image:width:unit"), "px")
+	assertEqual(t, len(image.All("og:image:width")), 2)
+}
+
+func TestDocument_Order(t *testing.T) {
+	// The grouping does not depend on the order of the properties which are not structured.
+	doc1 := NewDocument([]*Meta{
+		{Property: "og:locale", Content: "en_US"},
+		{Property: "og:locale:alternate", Content: "ja_JP"},
+	})
+	doc2 := NewDocument([]*Meta{
+		{Property: "og:locale:alternate", Content: "ja_JP"},
+		{Property: "og:locale", Content: "en_US"},
+	})
+	assertEqual(t, doc1.Map(), doc2.Map())
+	assertEqual(t, doc1.Map(), map[string]interface{}{"og:locale": "en_US", "og:locale:alternate": "ja_JP"})
+}
+
+func parseDocumentMetas(t *testing.T, html string) []*Meta {
+	metas, err := NewParser().parseMetas(strings.NewReader(html))
+	assertNoError(t, err)
+	return metas
+}
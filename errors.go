@@ -3,6 +3,10 @@ package googp
 import (
 	"errors"
 	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"strings"
 )
 
 var (
@@ -10,11 +14,66 @@ var (
 	ErrUnsupportedPage = errors.New("Unsupported page")
 )
 
-// BadStatusCodeError is an error returned when the status code is not 200 in Fetch.
+const (
+	badStatusCodeBodyMaxBytes = 1024
+)
+
+// BadStatusCodeError is an error returned when the status code is not accepted in Fetch.
 type BadStatusCodeError struct {
 	StatusCode int
+	// Header is the header of the response.
+	Header http.Header
+	// Body is the beginning of the body of the response, which is up to 1KiB.
+	Body []byte
 }
 
 func (err BadStatusCodeError) Error() string {
 	return fmt.Sprintf("Bad status code (%d)", err.StatusCode)
 }
+
+// newBadStatusCodeError create a `*BadStatusCodeError` with reading the beginning of the body.
+func newBadStatusCodeError(res *http.Response) *BadStatusCodeError {
+	err := &BadStatusCodeError{StatusCode: res.StatusCode, Header: res.Header}
+	if res.Body != nil {
+		err.Body, _ = ioutil.ReadAll(io.LimitReader(res.Body, badStatusCodeBodyMaxBytes))
+	}
+	return err
+}
+
+// UnsupportedContentTypeError is an error returned when the Content-Type of the response is not accepted.
+// It wraps ErrUnsupportedPage.
+type UnsupportedContentTypeError struct {
+	// ContentType is the media type of the response.
+	ContentType string
+	// Accepted is the media types accepted.
+	Accepted []string
+}
+
+func (err *UnsupportedContentTypeError) Error() string {
+	return fmt.Sprintf("%s (%s is not in %s)", ErrUnsupportedPage, err.ContentType, strings.Join(err.Accepted, ", "))
+}
+
+func (err *UnsupportedContentTypeError) Unwrap() error {
+	return ErrUnsupportedPage
+}
+
+// MissingRequiredError is an error returned when the required properties are absent.
+// The properties are specified by `required` option of the struct tag.
+type MissingRequiredError struct {
+	Properties []string
+}
+
+func (err *MissingRequiredError) Error() string {
+	return fmt.Sprintf("Required properties are missing (%s)", strings.Join(err.Properties, ", "))
+}
+
+// ConflictError is an error returned when a property has different values and ConflictFail policy is used.
+type ConflictError struct {
+	Property string
+	// Values is the first value and the conflicting value.
+	Values []string
+}
+
+func (err *ConflictError) Error() string {
+	return fmt.Sprintf("%s has conflicting values (%s)", err.Property, strings.Join(err.Values, ", "))
+}
@@ -0,0 +1,432 @@
+package googp
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"golang.org/x/net/html"
+	"golang.org/x/net/html/atom"
+)
+
+const (
+	defaultMaxRedirects    = 10
+	defaultMaxRefreshDelay = 5 * time.Second
+)
+
+var (
+	// ErrTooManyRedirects is an error returned when the number of redirects exceeds FetcherOpts.MaxRedirects.
+	ErrTooManyRedirects = errors.New("Too many redirects")
+	// ErrRedirectLoop is an error returned when the redirect goes back to the URL already visited.
+	ErrRedirectLoop = errors.New("Redirect loop detected")
+)
+
+// Fetcher fetches the contents and parses OGP information.
+type Fetcher struct {
+	opts   FetcherOpts
+	client *http.Client
+	// robotsClient is the client used to get robots.txt.
+	robotsClient *http.Client
+}
+
+// FetcherOpts is an option of Fetcher.
+type FetcherOpts struct {
+	// HTTP client used to get the contents. If it is nil, http.DefaultClient is used.
+	Client *http.Client
+	// ParserOpts is used to parse the contents.
+	ParserOpts ParserOpts
+	// Cache stores the contents, and they are used until expired or revalidated with ETag and Last-Modified.
+	// If it is nil, the contents are not cached.
+	Cache Cache
+	// Retry is a policy to retry when fetching fails transiently.
+	// If it is nil, it does not retry.
+	Retry *RetryPolicy
+	// Robots is consulted before fetching each URL including redirects,
+	// and ErrDisallowedByRobots is returned when the URL is disallowed.
+	// The contents are requested with `User-Agent` of RobotsOpts.UserAgent.
+	// If it is nil, robots.txt is not checked.
+	Robots *Robots
+	// NetworkPolicy restricts the destinations including redirects and robots.txt,
+	// and DestinationError is returned when it is not allowed.
+	// If it is nil, any destinations are allowed.
+	NetworkPolicy *NetworkPolicy
+	// Maximum number of redirects. Default is 10.
+	// If it is negative, ErrTooManyRedirects is returned when it is redirected.
+	MaxRedirects int
+	// If it is true, `<meta http-equiv="refresh" content="0; url=...">` is followed in the same way as redirects.
+	FollowMetaRefresh bool
+	// Maximum delay of `<meta http-equiv="refresh">` followed. Default is 5s.
+	// The refresh which has a longer delay (e.g. auto-reload pages) is not followed.
+	// If it is negative, only the refresh without delay is followed.
+	MaxRefreshDelay time.Duration
+}
+
+// FetchResult is a result of Fetcher.Do.
+type FetchResult struct {
+	// URL is the requested URL.
+	URL string
+	// FinalURL is the URL of the content after following the redirects.
+	FinalURL string
+	// Redirects is the chain of redirects in order.
+	Redirects []*Redirect
+}
+
+// Redirect is a redirect followed while fetching.
+type Redirect struct {
+	// URL is the URL that is redirected.
+	URL string `json:"url"`
+	// StatusCode is the status code of the redirect response.
+	// It is 0 when it is redirected by `<meta http-equiv="refresh">`.
+	StatusCode int `json:"status_code,omitempty"`
+}
+
+// fetchedPage is the contents of the fetched page.
+type fetchedPage struct {
+	metas        []*Meta
+	refresh      string
+	refreshDelay time.Duration
+}
+
+// NewFetcher create a `Fetcher`
+func NewFetcher(opts ...FetcherOpts) *Fetcher {
+	f := &Fetcher{}
+	switch len(opts) {
+	case 0:
+	case 1:
+		f.opts = opts[0]
+	default:
+		panic("Cannot specify multiple FetcherOpts")
+	}
+
+	f.client = f.opts.Client
+	if f.client == nil {
+		f.client = http.DefaultClient
+	}
+	if f.opts.Robots != nil {
+		f.robotsClient = f.opts.Robots.opts.Client
+	}
+	if f.opts.NetworkPolicy != nil {
+		f.client = f.opts.NetworkPolicy.NewClient(f.client)
+		if f.opts.Robots != nil {
+			f.robotsClient = f.opts.NetworkPolicy.NewClient(f.robotsClient)
+		}
+	}
+	return f
+}
+
+// Fetch the content from the URL and parse OGP information.
+func (f *Fetcher) Fetch(rawurl string, i interface{}) error {
+	return f.FetchContext(context.Background(), rawurl, i)
+}
+
+// FetchContext is the same as Fetch, but it uses the context while getting the content.
+func (f *Fetcher) FetchContext(ctx context.Context, rawurl string, i interface{}) error {
+	_, err := f.Do(ctx, rawurl, i)
+	return err
+}
+
+// Do is the same as FetchContext, but it returns the result which has the redirect chain and the final URL.
+// The result is returned even if it fails.
+func (f *Fetcher) Do(ctx context.Context, rawurl string, i interface{}) (*FetchResult, error) {
+	result := &FetchResult{URL: rawurl, FinalURL: rawurl}
+	for {
+		page, err := f.fetch(ctx, result, result.FinalURL)
+		if err != nil {
+			return result, err
+		}
+
+		if f.opts.FollowMetaRefresh && page.refresh != "" && page.refreshDelay <= f.maxRefreshDelay() {
+			base, err := url.Parse(result.FinalURL)
+			if err != nil {
+				return result, err
+			}
+			next, err := base.Parse(page.refresh)
+			if err != nil {
+				return result, fmt.Errorf("Invalid refresh URL: %w", err)
+			}
+			if err := f.checkRedirect(result, next); err != nil {
+				return result, err
+			}
+			result.Redirects = append(result.Redirects, &Redirect{URL: result.FinalURL})
+			result.FinalURL = next.String()
+			continue
+		}
+
+		return result, NewParser(f.opts.ParserOpts).setMetas(page.metas, i)
+	}
+}
+
+// fetch gets the content of the URL and parses the metas.
+// It updates the redirect chain and the final URL of the result.
+func (f *Fetcher) fetch(ctx context.Context, result *FetchResult, rawurl string) (*fetchedPage, error) {
+	if err := f.checkDestination(ctx, rawurl); err != nil {
+		return nil, err
+	}
+
+	var (
+		key   string
+		entry *CacheEntry
+	)
+	if f.opts.Cache != nil {
+		key = f.cacheKey(rawurl)
+		if e, ok := f.opts.Cache.Get(key); ok {
+			if time.Now().Before(e.Expires) {
+				result.Redirects = append(result.Redirects, e.Redirects...)
+				if e.FinalURL != "" {
+					result.FinalURL = e.FinalURL
+				}
+				return &fetchedPage{metas: e.Metas, refresh: e.Refresh, refreshDelay: e.RefreshDelay}, nil
+			}
+			entry = e
+		}
+	}
+
+	req, err := http.NewRequestWithContext(ctx, "GET", rawurl, nil)
+	if err != nil {
+		return nil, fmt.Errorf("Failed to get the content: %w", err)
+	}
+	if f.opts.Robots != nil {
+		// NOTE: The rules of robots.txt are for the user agent, so the contents are requested as the same one.
+		req.Header.Set("User-Agent", f.opts.Robots.userAgent())
+	}
+	if entry != nil {
+		if entry.ETag != "" {
+			req.Header.Set("If-None-Match", entry.ETag)
+		}
+		if entry.LastModified != "" {
+			req.Header.Set("If-Modified-Since", entry.LastModified)
+		}
+	}
+
+	client := *f.client
+	client.CheckRedirect = func(req *http.Request, via []*http.Request) error {
+		if err := f.checkRedirect(result, req.URL, via...); err != nil {
+			return err
+		}
+		if err := f.checkDestination(req.Context(), req.URL.String()); err != nil {
+			return err
+		}
+		if f.client.CheckRedirect != nil {
+			return f.client.CheckRedirect(req, via)
+		}
+		return nil
+	}
+
+	res, attempts, err := f.do(&client, req)
+	if err != nil {
+		return nil, fmt.Errorf("Failed to get the content: %w", err)
+	}
+	defer res.Body.Close()
+
+	redirects := make([]*Redirect, 0)
+	for r := res.Request; r.Response != nil; r = r.Response.Request {
+		redirects = append([]*Redirect{{URL: r.Response.Request.URL.String(), StatusCode: r.Response.StatusCode}}, redirects...)
+	}
+	result.Redirects = append(result.Redirects, redirects...)
+	result.FinalURL = res.Request.URL.String()
+
+	page := &fetchedPage{}
+	if entry != nil && res.StatusCode == http.StatusNotModified {
+		page.metas, page.refresh, page.refreshDelay = entry.Metas, entry.Refresh, entry.RefreshDelay
+	} else {
+		reader, err := newResponseReader(res, &f.opts.ParserOpts)
+		if err != nil {
+			if attempts > 1 {
+				return nil, &RetryError{Attempts: attempts, Err: err}
+			}
+			return nil, err
+		}
+
+		opts := f.opts.ParserOpts
+		if f.opts.FollowMetaRefresh {
+			preNodeFunc := opts.PreNodeFunc
+			opts.PreNodeFunc = func(n *html.Node) *Meta {
+				if preNodeFunc != nil {
+					if meta := preNodeFunc(n); meta != nil {
+						return meta
+					}
+				}
+				if refresh, delay := getRefreshURL(n); refresh != "" && page.refresh == "" {
+					page.refresh, page.refreshDelay = refresh, delay
+				}
+				return nil
+			}
+		}
+		if page.metas, err = NewParser(opts).parseMetas(reader); err != nil {
+			return nil, err
+		}
+		entry = &CacheEntry{}
+	}
+
+	if f.opts.Cache == nil {
+		return page, nil
+	}
+	if expires, ok := cacheExpires(res.Header, time.Now()); ok {
+		// NOTE: The redirects are followed again when revalidating, so the chain is always the latest one.
+		newEntry := &CacheEntry{
+			Metas:        page.metas,
+			FinalURL:     result.FinalURL,
+			Redirects:    redirects,
+			Refresh:      page.refresh,
+			RefreshDelay: page.refreshDelay,
+			ETag:         res.Header.Get("ETag"),
+			LastModified: res.Header.Get("Last-Modified"),
+			Expires:      expires,
+		}
+		// NOTE: 304 response may omit the validators.
+		if newEntry.ETag == "" {
+			newEntry.ETag = entry.ETag
+		}
+		if newEntry.LastModified == "" {
+			newEntry.LastModified = entry.LastModified
+		}
+		if newEntry.ETag != "" || newEntry.LastModified != "" || time.Now().Before(expires) {
+			f.opts.Cache.Set(key, newEntry)
+		}
+	}
+	return page, nil
+}
+
+// checkRedirect returns an error when the redirect to the URL exceeds the limit or goes back to the visited URL.
+// via is the requests in the current redirect chain of http.Client, which are not in the result yet.
+func (f *Fetcher) checkRedirect(result *FetchResult, next *url.URL, via ...*http.Request) error {
+	max := f.opts.MaxRedirects
+	if max == 0 {
+		max = defaultMaxRedirects
+	}
+
+	// NOTE: via contains the first request which is not a redirect.
+	count := len(result.Redirects) + 1
+	if len(via) > 0 {
+		count = len(result.Redirects) + len(via)
+	}
+	if count > max {
+		return fmt.Errorf("%w (%s)", ErrTooManyRedirects, next)
+	}
+
+	nextURL := next.String()
+	visited := nextURL == result.URL || nextURL == result.FinalURL
+	for _, r := range result.Redirects {
+		visited = visited || nextURL == r.URL
+	}
+	for _, r := range via {
+		visited = visited || nextURL == r.URL.String()
+	}
+	if visited {
+		return fmt.Errorf("%w (%s)", ErrRedirectLoop, next)
+	}
+	return nil
+}
+
+func (f *Fetcher) maxRefreshDelay() time.Duration {
+	switch {
+	case f.opts.MaxRefreshDelay < 0:
+		return 0
+	case f.opts.MaxRefreshDelay == 0:
+		return defaultMaxRefreshDelay
+	default:
+		return f.opts.MaxRefreshDelay
+	}
+}
+
+// cacheKey returns the key of Cache for the URL.
+// NOTE: The options which change the metas are a part of the key, so that a Cache can be shared between Fetchers.
+func (f *Fetcher) cacheKey(rawurl string) string {
+	var flags []string
+	if f.opts.ParserOpts.IncludeBody {
+		flags = append(flags, "body")
+	}
+	if f.opts.FollowMetaRefresh {
+		flags = append(flags, "refresh")
+	}
+
+	key := normalizeURL(rawurl)
+	if len(flags) > 0 {
+		key += " (" + strings.Join(flags, ",") + ")"
+	}
+	return key
+}
+
+// checkDestination returns an error when the URL is not allowed by NetworkPolicy or robots.txt.
+// NOTE: NetworkPolicy is checked first, so that robots.txt of the denied destination is never requested.
+func (f *Fetcher) checkDestination(ctx context.Context, rawurl string) error {
+	if f.opts.NetworkPolicy != nil {
+		u, err := url.Parse(rawurl)
+		if err != nil {
+			return fmt.Errorf("Failed to get the content: %w", err)
+		}
+		if err := f.opts.NetworkPolicy.checkURL(u); err != nil {
+			return err
+		}
+	}
+	if f.opts.Robots != nil {
+		return f.opts.Robots.check(ctx, f.robotsClient, rawurl)
+	}
+	return nil
+}
+
+// crawlDelay returns `Crawl-delay` of robots.txt for the host of the URL.
+func (f *Fetcher) crawlDelay(ctx context.Context, rawurl string) (time.Duration, error) {
+	if f.opts.Robots == nil {
+		return 0, nil
+	}
+	if err := f.checkDestination(ctx, rawurl); err != nil {
+		return 0, err
+	}
+	return f.opts.Robots.crawlDelay(ctx, f.robotsClient, rawurl)
+}
+
+// do sends the request, and returns the number of attempts.
+func (f *Fetcher) do(client *http.Client, req *http.Request) (*http.Response, int, error) {
+	if f.opts.Retry != nil {
+		return f.opts.Retry.do(client, req)
+	}
+	res, err := client.Do(req)
+	return res, 1, err
+}
+
+// getRefreshURL returns the URL and the delay of `<meta http-equiv="refresh" content="0; url=...">`.
+// It returns an empty string when the node is not such a meta tag.
+func getRefreshURL(n *html.Node) (string, time.Duration) {
+	if n.DataAtom != atom.Meta {
+		return "", 0
+	}
+
+	var httpEquiv, content string
+	for _, attr := range n.Attr {
+		switch attr.Key {
+		case "http-equiv":
+			httpEquiv = attr.Val
+		case "content":
+			content = attr.Val
+		}
+	}
+	if !strings.EqualFold(httpEquiv, "refresh") {
+		return "", 0
+	}
+
+	i := strings.IndexAny(content, ";,")
+	if i < 0 {
+		return "", 0
+	}
+	sec, err := strconv.ParseFloat(strings.TrimSpace(content[:i]), 64)
+	if err != nil || sec < 0 {
+		return "", 0
+	}
+	delay := time.Duration(sec * float64(time.Second))
+
+	content = strings.TrimSpace(content[i+1:])
+	if len(content) < 3 || !strings.EqualFold(content[:3], "url") {
+		return "", 0
+	}
+	content = strings.TrimSpace(content[3:])
+	if !strings.HasPrefix(content, "=") {
+		return "", 0
+	}
+	return strings.Trim(strings.TrimSpace(content[1:]), `"'`), delay
+}
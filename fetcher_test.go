@@ -0,0 +1,220 @@
+package googp
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"testing"
+	"time"
+
+	"golang.org/x/net/html"
+	"golang.org/x/net/html/atom"
+)
+
+func TestFetcher_Fetch(t *testing.T) {
+	server := httptest.NewServer(http.FileServer(http.Dir("data")))
+	defer server.Close()
+
+	fetcher := NewFetcher()
+	var ogp OGP
+	assertNoError(t, fetcher.Fetch(server.URL+"/1.html", &ogp))
+
+	assertEqual(t, ogp.Title, "title")
+	assertEqual(t, ogp.Type, "website")
+	assertEqual(t, ogp.URL, "http://example.com")
+	assertEqual(t, ogp.Images[0].URL, "http://example.com/image.png")
+
+	var statusErr *BadStatusCodeError
+	assertEqual(t, errors.As(fetcher.Fetch(server.URL+"/notfound.html", &ogp), &statusErr), true)
+	assertEqual(t, statusErr.StatusCode, 404)
+}
+
+func TestFetcher_Fetch_Client(t *testing.T) {
+	var userAgent string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		userAgent = r.Header.Get("User-Agent")
+		http.ServeFile(w, r, "data/1.html")
+	}))
+	defer server.Close()
+
+	client := &http.Client{Transport: roundTripperFunc(func(req *http.Request) (*http.Response, error) {
+		req.Header.Set("User-Agent", "googp-test")
+		return http.DefaultTransport.RoundTrip(req)
+	})}
+
+	fetcher := NewFetcher(FetcherOpts{Client: client})
+	var ogp OGP
+	assertNoError(t, fetcher.Fetch(server.URL, &ogp))
+	assertEqual(t, userAgent, "googp-test")
+	assertEqual(t, ogp.Title, "title")
+}
+
+func TestFetcher_FetchContext(t *testing.T) {
+	done := make(chan struct{})
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		select {
+		case <-done:
+		case <-r.Context().Done():
+		}
+	}))
+	defer server.Close()
+	defer close(done)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+
+	var ogp OGP
+	err := NewFetcher().FetchContext(ctx, server.URL, &ogp)
+	assertEqual(t, errors.Is(err, context.DeadlineExceeded), true)
+}
+
+type roundTripperFunc func(*http.Request) (*http.Response, error)
+
+func (f roundTripperFunc) RoundTrip(req *http.Request) (*http.Response, error) {
+	return f(req)
+}
+
+func mustOpen(t *testing.T, name string) *os.File {
+	f, err := os.Open(name)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { f.Close() })
+	return f
+}
+
+func redirectServer() *httptest.Server {
+	mux := http.NewServeMux()
+	mux.Handle("/", http.FileServer(http.Dir("data")))
+	mux.Handle("/a", http.RedirectHandler("/b", http.StatusMovedPermanently))
+	mux.Handle("/b", http.RedirectHandler("/1.html", http.StatusFound))
+	mux.Handle("/c", http.RedirectHandler("/refresh.html", http.StatusFound))
+	mux.Handle("/loop1", http.RedirectHandler("/loop2", http.StatusFound))
+	mux.Handle("/loop2", http.RedirectHandler("/loop1", http.StatusFound))
+	return httptest.NewServer(mux)
+}
+
+func TestFetcher_Do(t *testing.T) {
+	server := redirectServer()
+	defer server.Close()
+
+	var ogp OGP
+	result, err := NewFetcher().Do(context.Background(), server.URL+"/a", &ogp)
+	assertNoError(t, err)
+	assertEqual(t, ogp.Title, "title")
+	assertEqual(t, result.URL, server.URL+"/a")
+	assertEqual(t, result.FinalURL, server.URL+"/1.html")
+	assertEqual(t, result.Redirects, []*Redirect{
+		{URL: server.URL + "/a", StatusCode: 301},
+		{URL: server.URL + "/b", StatusCode: 302},
+	})
+
+	result, err = NewFetcher().Do(context.Background(), server.URL+"/1.html", &ogp)
+	assertNoError(t, err)
+	assertEqual(t, result.FinalURL, server.URL+"/1.html")
+	assertEqual(t, len(result.Redirects), 0)
+}
+
+func TestFetcher_Do_MaxRedirects(t *testing.T) {
+	server := redirectServer()
+	defer server.Close()
+
+	var ogp OGP
+	result, err := NewFetcher(FetcherOpts{MaxRedirects: 1}).Do(context.Background(), server.URL+"/a", &ogp)
+	assertEqual(t, errors.Is(err, ErrTooManyRedirects), true)
+	assertEqual(t, result.URL, server.URL+"/a")
+
+	_, err = NewFetcher(FetcherOpts{MaxRedirects: -1}).Do(context.Background(), server.URL+"/b", &ogp)
+	assertEqual(t, errors.Is(err, ErrTooManyRedirects), true)
+
+	_, err = NewFetcher(FetcherOpts{MaxRedirects: 2}).Do(context.Background(), server.URL+"/a", &ogp)
+	assertNoError(t, err)
+}
+
+func TestFetcher_Do_RedirectLoop(t *testing.T) {
+	server := redirectServer()
+	defer server.Close()
+
+	var ogp OGP
+	_, err := NewFetcher().Do(context.Background(), server.URL+"/loop1", &ogp)
+	assertEqual(t, errors.Is(err, ErrRedirectLoop), true)
+}
+
+func TestFetcher_Do_MetaRefresh(t *testing.T) {
+	server := redirectServer()
+	defer server.Close()
+
+	var ogp OGP
+	result, err := NewFetcher().Do(context.Background(), server.URL+"/c", &ogp)
+	assertNoError(t, err)
+	assertEqual(t, ogp.Title, "interstitial")
+	assertEqual(t, result.FinalURL, server.URL+"/refresh.html")
+
+	ogp = OGP{}
+	fetcher := NewFetcher(FetcherOpts{FollowMetaRefresh: true, Cache: NewMemoryCache(10)})
+	for i := 0; i < 2; i++ {
+		result, err = fetcher.Do(context.Background(), server.URL+"/c", &ogp)
+		assertNoError(t, err)
+		assertEqual(t, ogp.Title, "title")
+		assertEqual(t, result.FinalURL, server.URL+"/1.html")
+		assertEqual(t, result.Redirects, []*Redirect{
+			{URL: server.URL + "/c", StatusCode: 302},
+			{URL: server.URL + "/refresh.html", StatusCode: 0},
+		})
+	}
+
+	_, err = NewFetcher(FetcherOpts{FollowMetaRefresh: true, MaxRedirects: 1}).Do(context.Background(), server.URL+"/c", &ogp)
+	assertEqual(t, errors.Is(err, ErrTooManyRedirects), true)
+}
+
+func TestFetcher_Do_MetaRefreshDelay(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/1.html" {
+			http.ServeFile(w, r, "data/1.html")
+			return
+		}
+		w.Header().Set("Content-Type", "text/html")
+		w.Write([]byte(`<html><head><meta http-equiv="refresh" content="300; url=/1.html" />` +
+			`<meta property="og:title" content="reload" /></head></html>`))
+	}))
+	defer server.Close()
+
+	// The auto-reload page which has a long delay is not followed.
+	var ogp OGP
+	result, err := NewFetcher(FetcherOpts{FollowMetaRefresh: true}).Do(context.Background(), server.URL+"/", &ogp)
+	assertNoError(t, err)
+	assertEqual(t, ogp.Title, "reload")
+	assertEqual(t, result.FinalURL, server.URL+"/")
+
+	ogp = OGP{}
+	fetcher := NewFetcher(FetcherOpts{FollowMetaRefresh: true, MaxRefreshDelay: 10 * time.Minute})
+	result, err = fetcher.Do(context.Background(), server.URL+"/", &ogp)
+	assertNoError(t, err)
+	assertEqual(t, ogp.Title, "title")
+	assertEqual(t, result.FinalURL, server.URL+"/1.html")
+}
+
+func Test_GetRefreshURL(t *testing.T) {
+	newNode := func(attrs ...string) *html.Node {
+		n := &html.Node{Type: html.ElementNode, Data: "meta", DataAtom: atom.Meta}
+		for i := 0; i < len(attrs); i += 2 {
+			n.Attr = append(n.Attr, html.Attribute{Key: attrs[i], Val: attrs[i+1]})
+		}
+		return n
+	}
+
+	assertRefresh := func(n *html.Node, expectedURL string, expectedDelay time.Duration) {
+		t.Helper()
+		url, delay := getRefreshURL(n)
+		assertEqual(t, url, expectedURL)
+		assertEqual(t, delay, expectedDelay)
+	}
+	assertRefresh(newNode("http-equiv", "refresh", "content", "0; url=http://example.com/"), "http://example.com/", 0)
+	assertRefresh(newNode("http-equiv", "Refresh", "content", "5;URL='/next'"), "/next", 5*time.Second)
+	assertRefresh(newNode("http-equiv", "refresh", "content", `0.5, url = "/next"`), "/next", 500*time.Millisecond)
+	assertRefresh(newNode("http-equiv", "refresh", "content", "5"), "", 0)
+	assertRefresh(newNode("http-equiv", "refresh", "content", "soon; url=/next"), "", 0)
+	assertRefresh(newNode("property", "og:url", "content", "0; url=/next"), "", 0)
+}
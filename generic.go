@@ -0,0 +1,66 @@
+package googp
+
+import (
+	"fmt"
+	"reflect"
+)
+
+// genericAccessor is an accessor for writing the values of ogp to `interface{}` or `map[string]interface{}`.
+// The values are written when Finish is called, in the same way as Document.Map.
+type genericAccessor struct {
+	tag   *tag
+	value reflect.Value
+	doc   Document
+}
+
+// isGenericType returns true when the type is `interface{}` or `map[string]interface{}`.
+func isGenericType(t reflect.Type) bool {
+	switch t.Kind() {
+	case reflect.Interface:
+		return t.NumMethod() == 0
+	case reflect.Map:
+		return t.Key().Kind() == reflect.String && t.Elem().Kind() == reflect.Interface && t.Elem().NumMethod() == 0
+	default:
+		return false
+	}
+}
+
+func (ac *genericAccessor) Set(key string, val string) error {
+	ac.doc.add(key, val)
+	return nil
+}
+
+func (ac *genericAccessor) Finish() error {
+	if len(ac.doc.metas) == 0 {
+		return nil
+	}
+	m := ac.doc.Map()
+
+	if ac.value.Kind() == reflect.Map {
+		if ac.value.IsNil() {
+			if !ac.value.CanSet() {
+				return fmt.Errorf("Cannot set to value")
+			}
+			ac.value.Set(reflect.MakeMap(ac.value.Type()))
+		}
+		for k, v := range m {
+			ac.value.SetMapIndex(reflect.ValueOf(k).Convert(ac.value.Type().Key()), reflect.ValueOf(v))
+		}
+		return nil
+	}
+
+	if !ac.value.CanSet() {
+		return fmt.Errorf("Cannot set to value")
+	}
+	var v interface{} = m
+	// NOTE: The value of the field is unwrapped when it has only the property of the field. (e.g. `og:image` field)
+	if ac.tag != nil && len(m) == 1 {
+		for name, value := range m {
+			if ac.tag.isContainsName(name) {
+				v = value
+			}
+		}
+	}
+	ac.value.Set(reflect.ValueOf(v))
+	return nil
+}
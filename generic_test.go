@@ -0,0 +1,82 @@
+package googp
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestParser_Parse_Generic(t *testing.T) {
+	expected := map[string]interface{}{
+		"og:title": "title",
+		"og:image": []interface{}{
+			map[string]interface{}{
+				"og:image":        "http://example.com/image1.png",
+				"og:image:width":  "100",
+				"og:image:height": "200",
+			},
+			map[string]interface{}{
+				"og:image":       "http://example.com/image2.png",
+				"og:image:url":   "http://example.com/image3.png",
+				"og:image:width": "300",
+			},
+		},
+		"og:locale":           "en_US",
+		"og:locale:alternate": []interface{}{"ja_JP", "fr_FR"},
+	}
+
+	var m map[string]interface{}
+	assertNoError(t, NewParser().Parse(strings.NewReader(documentHTML), &m))
+	assertEqual(t, m, expected)
+
+	// The values are added to the existing map.
+	m = map[string]interface{}{"custom": "value"}
+	assertNoError(t, NewParser().Parse(strings.NewReader(documentHTML), &m))
+	assertEqual(t, m["custom"], "value")
+	assertEqual(t, m["og:title"], "title")
+
+	var i interface{}
+	assertNoError(t, NewParser().Parse(strings.NewReader(documentHTML), &i))
+	assertEqual(t, i, expected)
+
+	// The pointer stored in the interface is used.
+	var ogp OGP
+	i = &ogp
+	assertNoError(t, NewParser().Parse(strings.NewReader(documentHTML), &i))
+	assertEqual(t, ogp.Title, "title")
+}
+
+func TestParser_Parse_GenericField(t *testing.T) {
+	var v struct {
+		Title  interface{}            `googp:"og:title"`
+		Image  interface{}            `googp:"og:image"`
+		Locale map[string]interface{} `googp:"og:locale"`
+		None   interface{}            `googp:"og:description"`
+	}
+
+	assertNoError(t, NewParser().Parse(strings.NewReader(documentHTML), &v))
+	assertEqual(t, v.Title, "title")
+	assertEqual(t, reflect.ValueOf(v.Image).Len(), 2)
+	assertEqual(t, v.Locale, map[string]interface{}{
+		"og:locale":           "en_US",
+		"og:locale:alternate": []interface{}{"ja_JP", "fr_FR"},
+	})
+	assertEqual(t, v.None, nil)
+
+	var v2 struct {
+		Images []interface{} `googp:"og:image"`
+	}
+	assertNoError(t, NewParser().Parse(strings.NewReader(documentHTML), &v2))
+	assertEqual(t, v2.Images, []interface{}{
+		map[string]interface{}{
+			"og:image":        "http://example.com/image1.png",
+			"og:image:width":  "100",
+			"og:image:height": "200",
+		},
+		map[string]interface{}{
+			"og:image":       "http://example.com/image2.png",
+			"og:image:url":   "http://example.com/image3.png",
+			"og:image:width": "300",
+		},
+	})
+}
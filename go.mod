@@ -2,4 +2,7 @@ module github.com/soranoba/googp
 
 go 1.15
 
-require golang.org/x/net v0.10.0
+require (
+	golang.org/x/net v0.10.0
+	golang.org/x/text v0.9.0
+)
@@ -12,46 +12,196 @@ package googp
 
 import (
 	"bufio"
+	"bytes"
 	"fmt"
 	"io"
 	"mime"
 	"net/http"
+	"os"
+	"strings"
 
+	"golang.org/x/net/html"
 	"golang.org/x/net/html/charset"
+	"golang.org/x/text/encoding"
 )
 
 // Fetch the content from the URL and parse OGP information.
 func Fetch(rawurl string, i interface{}, opts ...ParserOpts) error {
-	res, err := http.Get(rawurl)
-	if err != nil {
-		return fmt.Errorf("Failed to get the content: %w", err)
+	var fetcherOpts FetcherOpts
+	switch len(opts) {
+	case 0:
+	case 1:
+		fetcherOpts.ParserOpts = opts[0]
+	default:
+		panic("Cannot specify multiple ParserOpts")
 	}
-	return Parse(res, i, opts...)
+	return NewFetcher(fetcherOpts).Fetch(rawurl, i)
 }
 
 // Parse OGP information.
-// It returns an error when the status code of the response is error.
+// It returns BadStatusCodeError when the status code of the response is not accepted.
 func Parse(res *http.Response, i interface{}, opts ...ParserOpts) error {
-	if res.StatusCode != 200 {
-		return &BadStatusCodeError{StatusCode: res.StatusCode}
+	parser := NewParser(opts...)
+	reader, err := newResponseReader(res, &parser.opts)
+	if err != nil {
+		return err
 	}
+	return parser.Parse(reader, i)
+}
 
-	ct := res.Header.Get("Content-Type")
-	if ct != "" {
-		mt, _, err := mime.ParseMediaType(ct)
-		if err != nil {
-			return fmt.Errorf("Invalid Content-Type: %w", err)
-		}
-		if mt != "text/html" {
-			return fmt.Errorf("%w (%s)", ErrUnsupportedPage, mt)
+// AcceptStatusCodes returns a function for ParserOpts.AcceptStatus that accepts the status codes.
+func AcceptStatusCodes(codes ...int) func(statusCode int) bool {
+	return func(statusCode int) bool {
+		for _, code := range codes {
+			if code == statusCode {
+				return true
+			}
 		}
+		return false
+	}
+}
+
+// newResponseReader checks the response and returns the reader of the body decoded to UTF-8.
+func newResponseReader(res *http.Response, opts *ParserOpts) (io.Reader, error) {
+	acceptStatus := opts.AcceptStatus
+	if acceptStatus == nil {
+		acceptStatus = AcceptStatusCodes(http.StatusOK)
+	}
+	if !acceptStatus(res.StatusCode) {
+		return nil, newBadStatusCodeError(res)
 	}
 
 	br := bufio.NewReader(res.Body)
-	var reader io.Reader = br
 	data, _ := br.Peek(1024)
-	enc, _, _ := charset.DetermineEncoding(data, ct)
-	reader = enc.NewDecoder().Reader(reader)
 
-	return NewParser(opts...).Parse(reader, i)
+	ct := res.Header.Get("Content-Type")
+	if err := opts.checkContentType(ct, data); err != nil {
+		return nil, err
+	}
+
+	return newDecodedReader(br, ct), nil
+}
+
+// ParseReader parses OGP information from the HTML, with detecting the charset in the same way as Parse.
+//
+// contentType is a declared Content-Type (e.g. `text/html; charset=shift_jis`) or a charset (e.g. `shift_jis`).
+// If it is empty, the charset is detected from BOM and `<meta charset>`, and it is regarded as UTF-8 when they are absent.
+func ParseReader(r io.Reader, contentType string, i interface{}, opts ...ParserOpts) error {
+	if contentType != "" && !strings.Contains(contentType, "/") {
+		contentType = "text/html; charset=" + contentType
+	}
+	return NewParser(opts...).Parse(newDecodedReader(bufio.NewReader(r), contentType), i)
+}
+
+// ParseBytes is the same as ParseReader, but it parses the bytes.
+func ParseBytes(b []byte, contentType string, i interface{}, opts ...ParserOpts) error {
+	return ParseReader(bytes.NewReader(b), contentType, i, opts...)
+}
+
+// ParseString is the same as ParseReader, but it parses the string.
+func ParseString(s string, contentType string, i interface{}, opts ...ParserOpts) error {
+	return ParseReader(strings.NewReader(s), contentType, i, opts...)
+}
+
+// ParseFile is the same as ParseReader, but it parses the file.
+func ParseFile(name string, contentType string, i interface{}, opts ...ParserOpts) error {
+	f, err := os.Open(name)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+	return ParseReader(f, contentType, i, opts...)
+}
+
+// newDecodedReader returns the reader decoded to UTF-8.
+// The charset is determined by BOM, the Content-Type and `<meta charset>`, in that order, and it is UTF-8 when they are absent.
+func newDecodedReader(br *bufio.Reader, contentType string) io.Reader {
+	data, _ := br.Peek(1024)
+	enc, name, certain := charset.DetermineEncoding(data, contentType)
+	// NOTE: DetermineEncoding falls back to windows-1252 when the data is ASCII only or it is not valid UTF-8.
+	if !certain && name == "windows-1252" && !declaresCharset(data) {
+		enc = encoding.Nop
+	}
+
+	// NOTE: The decoders do not remove BOM, and html.Parse regards it as a text.
+	switch {
+	case bytes.HasPrefix(data, []byte("\xef\xbb\xbf")):
+		br.Discard(3)
+	case bytes.HasPrefix(data, []byte("\xfe\xff")), bytes.HasPrefix(data, []byte("\xff\xfe")):
+		br.Discard(2)
+	}
+	return enc.NewDecoder().Reader(br)
+}
+
+// declaresCharset returns true, when the data has `<meta charset>` or `<meta content>` which has the charset.
+func declaresCharset(data []byte) bool {
+	z := html.NewTokenizer(bytes.NewReader(data))
+	for {
+		switch z.Next() {
+		case html.ErrorToken:
+			return false
+		case html.StartTagToken, html.SelfClosingTagToken:
+			name, hasAttr := z.TagName()
+			if string(name) != "meta" {
+				continue
+			}
+			for hasAttr {
+				var key, val []byte
+				key, val, hasAttr = z.TagAttr()
+				switch string(key) {
+				case "charset":
+					return true
+				case "content":
+					if _, params, err := mime.ParseMediaType(string(val)); err == nil && params["charset"] != "" {
+						return true
+					}
+				}
+			}
+		}
+	}
+}
+
+// checkContentType returns an error when the Content-Type is not accepted.
+// When it is not accepted and SniffContentType is true, the media type detected from the data is checked instead.
+func (opts *ParserOpts) checkContentType(ct string, data []byte) error {
+	if ct == "" {
+		return nil
+	}
+
+	accepted := opts.AcceptContentTypes
+	if len(accepted) == 0 {
+		accepted = defaultAcceptContentTypes
+	}
+
+	mt, _, err := mime.ParseMediaType(ct)
+	if err == nil && matchMediaType(accepted, mt) {
+		return nil
+	}
+
+	if opts.SniffContentType {
+		sniffed, _, _ := mime.ParseMediaType(http.DetectContentType(data))
+		if matchMediaType(accepted, sniffed) {
+			return nil
+		}
+	}
+
+	if err != nil {
+		return fmt.Errorf("Invalid Content-Type: %w", err)
+	}
+	return &UnsupportedContentTypeError{ContentType: mt, Accepted: accepted}
+}
+
+// matchMediaType returns true when the media type matches one of the patterns.
+// The pattern can have a wildcard subtype, e.g. `text/*`.
+func matchMediaType(patterns []string, mt string) bool {
+	for _, pattern := range patterns {
+		pattern = strings.ToLower(pattern)
+		if pattern == mt || pattern == "*/*" {
+			return true
+		}
+		if strings.HasSuffix(pattern, "/*") && strings.HasPrefix(mt, pattern[:len(pattern)-1]) {
+			return true
+		}
+	}
+	return false
 }
@@ -3,13 +3,19 @@ package googp
 import (
 	"context"
 	"encoding"
+	"errors"
 	"fmt"
+	"io/ioutil"
 	"net/http"
 	"net/url"
 	"reflect"
 	"runtime"
+	"strings"
 	"testing"
 	"time"
+
+	"golang.org/x/text/encoding/japanese"
+	"golang.org/x/text/encoding/unicode"
 )
 
 func TestFetch(t *testing.T) {
@@ -67,6 +73,160 @@ func TestParseWithEncoding(t *testing.T) {
 	assertEqual(t, ogp.Images[0].URL, "http://example.com/image.png")
 }
 
+func TestParseString_UTF8Fallback(t *testing.T) {
+	padding := "<!-- " + strings.Repeat("a", 1100) + " -->"
+	html := "<html><head>" + padding + `<meta property="og:title" content="タイトル" /></head></html>`
+	var v struct {
+		Title string `googp:"og:title"`
+	}
+	assertNoError(t, ParseString(html, "", &v))
+	assertEqual(t, v.Title, "タイトル")
+
+	// The charset declared by `<meta>` is used even if the data is ASCII only.
+	html = `<html><head><meta charset="iso-8859-1">` + padding + `<meta property="og:title" content="caf` + "\xe9" + `" /></head></html>`
+	assertNoError(t, ParseString(html, "", &v))
+	assertEqual(t, v.Title, "café")
+}
+
+func newFileResponse(t *testing.T, statusCode int, contentType string, name string) *http.Response {
+	header := http.Header{}
+	if contentType != "" {
+		header.Set("Content-Type", contentType)
+	}
+	return &http.Response{StatusCode: statusCode, Header: header, Body: mustOpen(t, name)}
+}
+
+func TestParse_ContentType(t *testing.T) {
+	var ogp OGP
+	assertNoError(t, Parse(newFileResponse(t, 200, "text/html; charset=utf-8", "data/1.html"), &ogp))
+	assertEqual(t, ogp.Title, "title")
+
+	ogp = OGP{}
+	assertNoError(t, Parse(newFileResponse(t, 200, "", "data/1.html"), &ogp))
+	assertEqual(t, ogp.Title, "title")
+
+	err := Parse(newFileResponse(t, 200, "application/xhtml+xml", "data/1.html"), &ogp)
+	assertEqual(t, errors.Is(err, ErrUnsupportedPage), true)
+	var ctErr *UnsupportedContentTypeError
+	assertEqual(t, errors.As(err, &ctErr), true)
+	assertEqual(t, ctErr.ContentType, "application/xhtml+xml")
+	assertEqual(t, ctErr.Accepted, []string{"text/html"})
+	assertEqual(t, err.Error(), "Unsupported page (application/xhtml+xml is not in text/html)")
+
+	opts := ParserOpts{AcceptContentTypes: []string{"text/html", "application/xhtml+xml"}}
+	ogp = OGP{}
+	assertNoError(t, Parse(newFileResponse(t, 200, "application/xhtml+xml", "data/1.html"), &ogp, opts))
+	assertEqual(t, ogp.Title, "title")
+
+	opts = ParserOpts{AcceptContentTypes: []string{"text/*"}}
+	ogp = OGP{}
+	assertNoError(t, Parse(newFileResponse(t, 200, "text/plain", "data/1.html"), &ogp, opts))
+	assertEqual(t, ogp.Title, "title")
+
+	assertError(t, Parse(newFileResponse(t, 200, "text/html;;", "data/1.html"), &ogp))
+}
+
+func TestParse_SniffContentType(t *testing.T) {
+	opts := ParserOpts{SniffContentType: true}
+
+	var ogp OGP
+	assertNoError(t, Parse(newFileResponse(t, 200, "text/plain", "data/1.html"), &ogp, opts))
+	assertEqual(t, ogp.Title, "title")
+
+	ogp = OGP{}
+	assertNoError(t, Parse(newFileResponse(t, 200, "invalid", "data/1.html"), &ogp, opts))
+	assertEqual(t, ogp.Title, "title")
+
+	err := Parse(newFileResponse(t, 200, "application/octet-stream", "data/image.png"), &ogp, opts)
+	var ctErr *UnsupportedContentTypeError
+	assertEqual(t, errors.As(err, &ctErr), true)
+	assertEqual(t, ctErr.ContentType, "application/octet-stream")
+}
+
+func TestParse_StatusCode(t *testing.T) {
+	var ogp OGP
+	err := Parse(newFileResponse(t, 404, "text/html", "data/1.html"), &ogp)
+	var statusErr *BadStatusCodeError
+	assertEqual(t, errors.As(err, &statusErr), true)
+	assertEqual(t, statusErr.StatusCode, 404)
+	assertEqual(t, statusErr.Header.Get("Content-Type"), "text/html")
+	assertEqual(t, string(statusErr.Body), string(mustReadFile(t, "data/1.html")))
+	assertEqual(t, err.Error(), "Bad status code (404)")
+
+	err = Parse(newFileResponse(t, 203, "text/html", "data/2.html"), &ogp)
+	assertEqual(t, errors.As(err, &statusErr), true)
+	assertEqual(t, len(statusErr.Body), 1024)
+
+	opts := ParserOpts{AcceptStatus: AcceptStatusCodes(200, 203, 404)}
+	assertNoError(t, Parse(newFileResponse(t, 203, "text/html", "data/1.html"), &ogp, opts))
+	assertEqual(t, ogp.Title, "title")
+	ogp = OGP{}
+	assertNoError(t, Parse(newFileResponse(t, 404, "text/html", "data/1.html"), &ogp, opts))
+	assertEqual(t, ogp.Title, "title")
+
+	opts = ParserOpts{AcceptStatus: func(statusCode int) bool { return statusCode/100 == 2 }}
+	assertNoError(t, Parse(newFileResponse(t, 206, "text/html", "data/1.html"), &ogp, opts))
+	assertError(t, Parse(newFileResponse(t, 410, "text/html", "data/1.html"), &ogp, opts))
+}
+
+func mustReadFile(t *testing.T, name string) []byte {
+	data, err := ioutil.ReadFile(name)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return data
+}
+
+func TestParseFile(t *testing.T) {
+	var ogp OGP
+	assertNoError(t, ParseFile("data/6.html", "", &ogp))
+	assertEqual(t, ogp.Title, "ShiftJISタイトル")
+	assertEqual(t, ogp.Type, "website")
+
+	// The declared charset is used when `<meta charset>` is absent.
+	ogp = OGP{}
+	assertNoError(t, ParseFile("data/1.html", "utf-8", &ogp))
+	assertEqual(t, ogp.Title, "title")
+
+	assertError(t, ParseFile("data/notfound.html", "", &ogp))
+}
+
+func TestParseBytes(t *testing.T) {
+	html := `<html><head><meta property="og:title" content="タイトル" /></head></html>`
+	sjis, err := japanese.ShiftJIS.NewEncoder().String(html)
+	assertNoError(t, err)
+
+	var ogp OGP
+	assertNoError(t, ParseBytes([]byte(sjis), "shift_jis", &ogp))
+	assertEqual(t, ogp.Title, "タイトル")
+
+	ogp = OGP{}
+	assertNoError(t, ParseBytes([]byte(sjis), "text/html; charset=Shift_JIS", &ogp))
+	assertEqual(t, ogp.Title, "タイトル")
+
+	// BOM has priority over the declared charset.
+	ogp = OGP{}
+	assertNoError(t, ParseBytes(append([]byte("\xef\xbb\xbf"), html...), "shift_jis", &ogp))
+	assertEqual(t, ogp.Title, "タイトル")
+
+	utf16, err := unicode.UTF16(unicode.LittleEndian, unicode.UseBOM).NewEncoder().String(html)
+	assertNoError(t, err)
+	ogp = OGP{}
+	assertNoError(t, ParseString(utf16, "", &ogp))
+	assertEqual(t, ogp.Title, "タイトル")
+
+	ogp = OGP{}
+	assertNoError(t, ParseString(html, "", &ogp))
+	assertEqual(t, ogp.Title, "タイトル")
+
+	type Title struct {
+		Title string `googp:"og:title"`
+	}
+	var title Title
+	assertNoError(t, ParseReader(strings.NewReader(html), "", &title, ParserOpts{IncludeBody: true}))
+	assertEqual(t, title.Title, "タイトル")
+}
+
 func ExampleFetch() {
 	var ogp OGP
 	if err := Fetch(endpoint()+"/5.html", &ogp); err != nil {
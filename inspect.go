@@ -0,0 +1,156 @@
+package googp
+
+import (
+	"reflect"
+	"strings"
+)
+
+// PropertyKind is a kind of the properties accepted by a field.
+type PropertyKind int
+
+const (
+	// PropertySingleton is a field which accepts a single value. (e.g. `string`, `int` and encoding.TextUnmarshaler)
+	PropertySingleton PropertyKind = iota + 1
+	// PropertyArray is a field which accepts the repeated properties. (e.g. `[]string` and `[]googp.Image`)
+	PropertyArray
+	// PropertyStructured is a field which accepts the structured properties. (e.g. `googp.Image` and Unmarshaler)
+	PropertyStructured
+	// PropertyDynamic is a field which accepts any properties under the names.
+	// (e.g. `map[string]string`, `interface{}` and `googp.Document`)
+	PropertyDynamic
+)
+
+func (k PropertyKind) String() string {
+	switch k {
+	case PropertySingleton:
+		return "singleton"
+	case PropertyArray:
+		return "array"
+	case PropertyStructured:
+		return "structured"
+	case PropertyDynamic:
+		return "dynamic"
+	default:
+		return "unknown"
+	}
+}
+
+// FieldInfo is a mapping between the properties and a field of a struct.
+type FieldInfo struct {
+	// Path is the path of the Go field from the root type. (e.g. `Images.URL`)
+	// The promoted fields have the names of the embedded structs. (e.g. `OGP.Title`)
+	Path string
+	// Index is the index sequence of the field in the struct, which is the same as reflect.StructField.Index.
+	Index []int
+	// Names is the property names which the field accepts. The relative names are resolved.
+	// (e.g. `og:image` and `og:image:url`)
+	//
+	// NOTE: The relative names of the root type are not resolved (e.g. `:url` of `googp.Image`),
+	// since they have no parent and they never match the properties.
+	Names []string
+	Kind  PropertyKind
+	// If it is true, the name is inferred from the field name since the field does not have the tag.
+	Inferred bool
+	// If it is true, the field has `required` option.
+	Required bool
+	// Default is the value of `default` option. If it is nil, the field does not have the option.
+	Default *string
+	// If it is true, the field has `alternates` option.
+	Alternates bool
+	// If it is true, the field is an embedded field which is not inlined. (e.g. `googp.Document`)
+	Embedded bool
+	// Fields is the fields of the struct, or the element of the array. (e.g. `googp.Image` of `[]googp.Image`)
+	Fields []*FieldInfo
+}
+
+// Inspect returns the fields of the struct type and the properties which they accept, in the same way as Parser.Parse.
+// If the type is not a struct, it returns nil.
+//
+// The fields ignored by `-` and the unexported fields are not included.
+// The fields of a recursive type are included only once in each path.
+func Inspect(t reflect.Type, opts ...ParserOpts) []*FieldInfo {
+	return NewParser(opts...).decoder().inspect(t)
+}
+
+func (d *decoder) inspect(t reflect.Type) []*FieldInfo {
+	t = indirectType(t)
+	if d.typeKind(t) != structKind {
+		return nil
+	}
+	return d.inspectStruct(t, "", "", map[reflect.Type]bool{})
+}
+
+func (d *decoder) inspectStruct(t reflect.Type, path string, root string, visited map[reflect.Type]bool) []*FieldInfo {
+	visited[t] = true
+	defer delete(visited, t)
+
+	var fields []*FieldInfo
+	for _, f := range cachedStructPlan(t, d.rules).fields {
+		if len(f.tag.names) == 0 {
+			continue
+		}
+
+		structField := t.FieldByIndex(f.index)
+		tag := f.tag.resolve(root)
+		info := &FieldInfo{
+			Path:       fieldPath(t, f.index),
+			Index:      f.index,
+			Names:      tag.names,
+			Inferred:   tag.inferred,
+			Required:   tag.required,
+			Default:    tag.defaultValue,
+			Alternates: tag.alternates,
+			Embedded:   structField.Anonymous,
+		}
+		if path != "" {
+			info.Path = path + "." + info.Path
+		}
+
+		ft := indirectType(structField.Type)
+		kind := d.typeKind(ft)
+		info.Kind = propertyKind(kind)
+		if kind == arrayKind {
+			ft = indirectType(ft.Elem())
+			kind = d.typeKind(ft)
+		}
+		if kind == structKind && !visited[ft] {
+			info.Fields = d.inspectStruct(ft, info.Path, rootName(tag), visited)
+		}
+		fields = append(fields, info)
+	}
+	return fields
+}
+
+// fieldPath returns the path of the field which has the index sequence. (e.g. `OGP.Title`)
+func fieldPath(t reflect.Type, index []int) string {
+	names := make([]string, 0, len(index))
+	for _, i := range index {
+		t = indirectType(t)
+		f := t.Field(i)
+		names = append(names, f.Name)
+		t = f.Type
+	}
+	return strings.Join(names, ".")
+}
+
+// propertyKind returns the kind of the properties accepted by the accessor.
+func propertyKind(kind accessorKind) PropertyKind {
+	switch kind {
+	case arrayKind:
+		return PropertyArray
+	case structKind, unmarshalerKind:
+		return PropertyStructured
+	case mapKind, genericKind, documentKind:
+		return PropertyDynamic
+	default:
+		return PropertySingleton
+	}
+}
+
+// indirectType returns the type which the pointers are dereferenced.
+func indirectType(t reflect.Type) reflect.Type {
+	for t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+	return t
+}
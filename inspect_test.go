@@ -0,0 +1,98 @@
+package googp
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestInspect(t *testing.T) {
+	type Node struct {
+		Name     string  `googp:"og:title,required"`
+		Children []*Node `googp:"og:child"`
+	}
+	type Card struct {
+		Site   string
+		Images []Image            `googp:":image"`
+		Extra  map[string]string  `googp:":*"`
+		Price  Money              `googp:"product:price"`
+		Type   string             `googp:":type,default=summary"`
+		Others []string           `googp:":other,alternates"`
+		Node   *Node              `googp:"og:node"`
+		Ignore string             `googp:"-"`
+		any    interface{}        `googp:"og:any"`
+		Doc    Document           `googp:"og"`
+		Value  *map[string]string `googp:"og:value"`
+	}
+
+	defaultValue := "summary"
+	assertEqual(t, Inspect(reflect.TypeOf(&Card{}), ParserOpts{DefaultNamespace: "twitter"}), []*FieldInfo{
+		{Path: "Site", Index: []int{0}, Names: []string{"twitter:site"}, Kind: PropertySingleton, Inferred: true},
+		{Path: "Images", Index: []int{1}, Names: []string{":image"}, Kind: PropertyArray, Fields: []*FieldInfo{
+			{Path: "Images.URL", Index: []int{0}, Names: []string{"og:image", "og:image:url", ":image", ":image:url"}, Kind: PropertySingleton},
+			{Path: "Images.SecureURL", Index: []int{1}, Names: []string{"og:image:secure_url", ":image:secure_url"}, Kind: PropertySingleton},
+			{Path: "Images.Type", Index: []int{2}, Names: []string{"og:image:type", ":image:type"}, Kind: PropertySingleton},
+			{Path: "Images.Width", Index: []int{3}, Names: []string{"og:image:width", ":image:width"}, Kind: PropertySingleton},
+			{Path: "Images.Height", Index: []int{4}, Names: []string{"og:image:height", ":image:height"}, Kind: PropertySingleton},
+			{Path: "Images.Alt", Index: []int{5}, Names: []string{"og:image:alt", ":image:alt"}, Kind: PropertySingleton},
+		}},
+		{Path: "Extra", Index: []int{2}, Names: []string{":*"}, Kind: PropertyDynamic},
+		{Path: "Price", Index: []int{3}, Names: []string{"product:price"}, Kind: PropertyStructured},
+		{Path: "Type", Index: []int{4}, Names: []string{":type"}, Kind: PropertySingleton, Default: &defaultValue},
+		{Path: "Others", Index: []int{5}, Names: []string{":other"}, Kind: PropertyArray, Alternates: true},
+		{Path: "Node", Index: []int{6}, Names: []string{"og:node"}, Kind: PropertyStructured, Fields: []*FieldInfo{
+			{Path: "Node.Name", Index: []int{0}, Names: []string{"og:title"}, Kind: PropertySingleton, Required: true},
+			{Path: "Node.Children", Index: []int{1}, Names: []string{"og:child"}, Kind: PropertyArray},
+		}},
+		{Path: "Doc", Index: []int{9}, Names: []string{"og"}, Kind: PropertyDynamic},
+		{Path: "Value", Index: []int{10}, Names: []string{"og:value"}, Kind: PropertyDynamic},
+	})
+
+	assertEqual(t, Inspect(reflect.TypeOf("")), []*FieldInfo(nil))
+	assertEqual(t, PropertyArray.String(), "array")
+}
+
+func TestInspect_Relative(t *testing.T) {
+	type Card struct {
+		Images []Image `googp:"twitter:image"`
+	}
+
+	fields := Inspect(reflect.TypeOf(Card{}))
+	assertEqual(t, fields[0].Fields[1], &FieldInfo{
+		Path:  "Images.SecureURL",
+		Index: []int{1},
+		Names: []string{"og:image:secure_url", "twitter:image:secure_url"},
+		Kind:  PropertySingleton,
+	})
+}
+
+func TestInspect_DuplicatedNames(t *testing.T) {
+	var names []string
+	for _, f := range Inspect(reflect.TypeOf(OGP{})) {
+		if f.Path == "Images" {
+			names = f.Fields[0].Names
+		}
+	}
+	assertEqual(t, names, []string{"og:image", "og:image:url"})
+
+	// The relative names of the root type are not resolved.
+	fields := Inspect(reflect.TypeOf(Image{}))
+	assertEqual(t, fields[0].Names, []string{"og:image", "og:image:url", ":", ":url"})
+}
+
+func TestInspect_Embedded(t *testing.T) {
+	type Article struct {
+		Author string `googp:"article:author"`
+		Title  string `googp:"article:title"`
+	}
+	type Page struct {
+		*Article
+		Extra Document `googp:"-"`
+		Document
+	}
+
+	assertEqual(t, Inspect(reflect.TypeOf(Page{})), []*FieldInfo{
+		{Path: "Article.Author", Index: []int{0, 0}, Names: []string{"article:author"}, Kind: PropertySingleton},
+		{Path: "Article.Title", Index: []int{0, 1}, Names: []string{"article:title"}, Kind: PropertySingleton},
+		{Path: "Document", Index: []int{2}, Names: []string{""}, Kind: PropertyDynamic, Embedded: true},
+	})
+}
@@ -0,0 +1,246 @@
+package googp
+
+import (
+	"fmt"
+	"reflect"
+	"strings"
+	"sync"
+)
+
+// ModelProblemKind is a kind of ModelProblem.
+type ModelProblemKind int
+
+const (
+	// ModelDuplicateName means that the property is claimed by multiple fields, and the later fields are never used.
+	ModelDuplicateName ModelProblemKind = iota + 1
+	// ModelUnreachableName means that the property of the nested field can never match,
+	// because it is not under the properties of the parent field.
+	ModelUnreachableName
+	// ModelEmbeddedCollision means that the promoted field of the embedded struct is shadowed by another field,
+	// or the embedded field which is not inlined is shadowed by another embedded field.
+	ModelEmbeddedCollision
+	// ModelUnknownName means that the property in `og:` namespace is not defined in the reference.
+	ModelUnknownName
+)
+
+// ModelProblem is a problem of the mapping between a struct type and the properties.
+type ModelProblem struct {
+	Kind ModelProblemKind
+	// Path is the path of the Go field. (e.g. `Images.URL`)
+	Path string
+	// Name is the name of the property.
+	Name string
+	// Message describes the problem.
+	Message string
+}
+
+// ModelError is an error returned when the struct type has problems.
+type ModelError struct {
+	Type     reflect.Type
+	Problems []*ModelProblem
+}
+
+func (err *ModelError) Error() string {
+	messages := make([]string, 0, len(err.Problems))
+	for _, p := range err.Problems {
+		messages = append(messages, p.Message)
+	}
+	return fmt.Sprintf("Invalid model %s (%s)", err.Type, strings.Join(messages, ", "))
+}
+
+var (
+	// knownOGPNames is the names of `og:` namespace.
+	// ref: https://ogp.me/
+	// ref: https://developers.facebook.com/docs/sharing/webmasters
+	knownOGPNames = map[string]bool{
+		"og:title":                           true,
+		"og:type":                            true,
+		"og:url":                             true,
+		"og:description":                     true,
+		"og:determiner":                      true,
+		"og:locale":                          true,
+		"og:locale:alternate":                true,
+		"og:site_name":                       true,
+		"og:image":                           true,
+		"og:image:url":                       true,
+		"og:image:secure_url":                true,
+		"og:image:type":                      true,
+		"og:image:width":                     true,
+		"og:image:height":                    true,
+		"og:image:alt":                       true,
+		"og:video":                           true,
+		"og:video:url":                       true,
+		"og:video:secure_url":                true,
+		"og:video:type":                      true,
+		"og:video:width":                     true,
+		"og:video:height":                    true,
+		"og:audio":                           true,
+		"og:audio:url":                       true,
+		"og:audio:secure_url":                true,
+		"og:audio:type":                      true,
+		"og:updated_time":                    true,
+		"og:see_also":                        true,
+		"og:ttl":                             true,
+		"og:rich_attachment":                 true,
+		"og:restrictions:age":                true,
+		"og:restrictions:content":            true,
+		"og:restrictions:country:allowed":    true,
+		"og:restrictions:country:disallowed": true,
+	}
+	modelErrorCache sync.Map // map[structPlanKey]error
+)
+
+// CheckModel returns ModelError when the struct type has problems below.
+//
+//   - The property is claimed by multiple fields.
+//   - The property of the nested field is not under the properties of the parent field.
+//   - The property of the embedded struct is shadowed.
+//   - The property in `og:` namespace is not defined in the reference. (e.g. `og:imgae`)
+//
+// It is also checked by Parser.Parse in strict mode.
+func CheckModel(t reflect.Type, opts ...ParserOpts) error {
+	d := NewParser(opts...).decoder()
+	return d.checkModel(t)
+}
+
+// MustCheckModel is like CheckModel but panics when the struct type has problems.
+// It can be used at initialization. (e.g. `var _ = googp.MustCheckModel(reflect.TypeOf(MyOGP{}))`)
+func MustCheckModel(t reflect.Type, opts ...ParserOpts) bool {
+	if err := CheckModel(t, opts...); err != nil {
+		panic(err)
+	}
+	return true
+}
+
+// checkModel returns the result of CheckModel, which is cached.
+func (d *decoder) checkModel(t reflect.Type) error {
+	if t == nil {
+		return nil
+	}
+	key := structPlanKey{t: indirectType(t), rules: d.rules}
+	if err, ok := modelErrorCache.Load(key); ok {
+		if err == nil {
+			return nil
+		}
+		return err.(error)
+	}
+
+	var checker modelChecker
+	checker.checkFields(d.inspect(t), nil)
+
+	var err error
+	if len(checker.problems) > 0 {
+		err = &ModelError{Type: key.t, Problems: checker.problems}
+	}
+	modelErrorCache.Store(key, err)
+	return err
+}
+
+// modelChecker collects the problems of the fields.
+type modelChecker struct {
+	problems []*ModelProblem
+}
+
+func (c *modelChecker) report(kind ModelProblemKind, field *FieldInfo, name string, format string, args ...interface{}) {
+	c.problems = append(c.problems, &ModelProblem{
+		Kind:    kind,
+		Path:    field.Path,
+		Name:    name,
+		Message: fmt.Sprintf(format, args...),
+	})
+}
+
+// checkFields checks the fields of a struct, which receive the properties under the parents.
+// If the parents is nil, the fields receive all properties.
+func (c *modelChecker) checkFields(fields []*FieldInfo, parents []string) {
+	// NOTE: The owners are decided in the same way as newStructPlan. The owner is nil when the fields are ambiguous.
+	candidates := make(map[string][]*FieldInfo)
+	for _, field := range fields {
+		if field.Alternates {
+			continue
+		}
+		for _, name := range field.Names {
+			candidates[name] = append(candidates[name], field)
+		}
+	}
+	owners := make(map[string]*FieldInfo)
+	for name, candidate := range candidates {
+		owners[name] = nil
+		if i := dominantField(len(candidate), func(i int) (int, bool) {
+			return len(candidate[i].Index) - 1, candidate[i].Inferred
+		}); i >= 0 {
+			owners[name] = candidate[i]
+		}
+	}
+
+	for _, field := range fields {
+		for _, name := range field.Names {
+			if isRelativeName(name) {
+				// NOTE: The relative names are resolved when the struct is mounted under another field.
+				continue
+			}
+			if owner := owners[name]; !field.Alternates && owner != field {
+				switch {
+				case owner == nil:
+					c.report(ModelEmbeddedCollision, field, name, "%s of %s is never used since it is ambiguous", name, field.Path)
+				case name == "":
+					c.report(ModelEmbeddedCollision, field, name, "%s is never used since %s is embedded", field.Path, owner.Path)
+				case len(field.Index) > 1 || len(owner.Index) > 1:
+					c.report(ModelEmbeddedCollision, field, name, "%s of %s is shadowed by %s", name, field.Path, owner.Path)
+				default:
+					c.report(ModelDuplicateName, field, name, "%s of %s is never used since it is claimed by %s", name, field.Path, owner.Path)
+				}
+			}
+			if name == "" {
+				continue
+			}
+			if parents != nil && !isUnderNames(name, parents) {
+				c.report(ModelUnreachableName, field, name, "%s of %s can never match since it is not under %s", name, field.Path, strings.Join(parents, ", "))
+			}
+			if strings.HasPrefix(name, "og:") && !knownOGPNames[name] {
+				if _, ok := patternPrefix(name); !ok {
+					c.report(ModelUnknownName, field, name, "%s of %s is unknown", name, field.Path)
+				}
+			}
+		}
+
+		if len(field.Fields) > 0 {
+			// NOTE: If the names of the parent are not resolved, the fields are not checked whether they are reachable.
+			var names []string
+			if absolute := absoluteNames(field.Names); len(absolute) > 0 {
+				names = absolute
+			}
+			c.checkFields(field.Fields, names)
+		}
+	}
+}
+
+// isUnderNames returns true, when the name or the pattern is under one of the names.
+func isUnderNames(name string, names []string) bool {
+	if prefix, ok := patternPrefix(name); ok {
+		name = prefix
+	}
+	for _, n := range names {
+		if prefix, ok := patternPrefix(n); ok {
+			if prefix == "" || name == prefix || strings.HasPrefix(name, prefix+":") {
+				return true
+			}
+			continue
+		}
+		if name == n || strings.HasPrefix(name, n+":") {
+			return true
+		}
+	}
+	return false
+}
+
+// absoluteNames returns the names which are not relative.
+func absoluteNames(names []string) []string {
+	absolute := make([]string, 0, len(names))
+	for _, name := range names {
+		if !isRelativeName(name) {
+			absolute = append(absolute, name)
+		}
+	}
+	return absolute
+}
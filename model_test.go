@@ -0,0 +1,92 @@
+package googp
+
+import (
+	"errors"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestCheckModel(t *testing.T) {
+	assertNoError(t, CheckModel(reflect.TypeOf(OGP{})))
+	assertNoError(t, CheckModel(reflect.TypeOf(&Image{})))
+	assertNoError(t, CheckModel(reflect.TypeOf("")))
+	assertEqual(t, MustCheckModel(reflect.TypeOf(OGP{})), true)
+
+	type Extra struct {
+		Title string `googp:"og:title"`
+		Site  string `googp:"twitter:site"`
+	}
+	type Image struct {
+		URL   string `googp:"og:image"`
+		Width int    `googp:"og:video:width"`
+		Alt   string `googp:":alt"`
+	}
+	type Extras map[string]string
+	type Model struct {
+		Title  string  `googp:"og:title"`
+		Title2 string  `googp:"og:title"`
+		Titles string  `googp:"og:title,alternates"`
+		Images []Image `googp:"og:image"`
+		Desc   string  `googp:"og:desciption"`
+		Any    string  `googp:"og:*"`
+		Extra
+		OGP
+		Document
+		Extras
+	}
+
+	err := CheckModel(reflect.TypeOf(Model{}))
+	var modelErr *ModelError
+	assertEqual(t, errors.As(err, &modelErr), true)
+	assertEqual(t, modelErr.Type, reflect.TypeOf(Model{}))
+
+	type problem struct {
+		Kind ModelProblemKind
+		Path string
+		Name string
+	}
+	var problems []problem
+	for _, p := range modelErr.Problems {
+		problems = append(problems, problem{Kind: p.Kind, Path: p.Path, Name: p.Name})
+	}
+	assertEqual(t, problems, []problem{
+		{Kind: ModelDuplicateName, Path: "Title2", Name: "og:title"},
+		{Kind: ModelUnreachableName, Path: "Images.Width", Name: "og:video:width"},
+		{Kind: ModelUnknownName, Path: "Desc", Name: "og:desciption"},
+		{Kind: ModelEmbeddedCollision, Path: "Extra.Title", Name: "og:title"},
+		{Kind: ModelEmbeddedCollision, Path: "OGP.Title", Name: "og:title"},
+		{Kind: ModelEmbeddedCollision, Path: "OGP.Images", Name: "og:image"},
+		{Kind: ModelEmbeddedCollision, Path: "Extras", Name: ""},
+	})
+	assertEqual(t, modelErr.Problems[3].Message, "og:title of Extra.Title is shadowed by Title")
+	assertEqual(t, modelErr.Problems[6].Message, "Extras is never used since Document is embedded")
+	assertEqual(t, modelErr.Problems[0].Message, "og:title of Title2 is never used since it is claimed by Title")
+	assertEqual(t, strings.HasPrefix(err.Error(), "Invalid model googp.Model (og:title of Title2 is never used"), true)
+
+	// The result is cached.
+	assertEqual(t, CheckModel(reflect.TypeOf(Model{})), err)
+
+	defer func() {
+		assertEqual(t, recover(), err)
+	}()
+	MustCheckModel(reflect.TypeOf(Model{}))
+}
+
+func TestParser_Parse_StrictModel(t *testing.T) {
+	var v struct {
+		Title string `googp:"og:titel"`
+	}
+	html := `<html><head><meta property="og:title" content="title" /></head></html>`
+
+	assertNoError(t, NewParser().Parse(strings.NewReader(html), &v))
+
+	var modelErr *ModelError
+	err := NewParser(ParserOpts{Strict: true}).Parse(strings.NewReader(html), &v)
+	assertEqual(t, errors.As(err, &modelErr), true)
+	assertEqual(t, modelErr.Problems[0].Kind, ModelUnknownName)
+
+	var ogp OGP
+	assertNoError(t, NewParser(ParserOpts{Strict: true}).Parse(strings.NewReader(html), &ogp))
+	assertEqual(t, ogp.Title, "title")
+}
@@ -8,6 +8,10 @@ import (
 	"golang.org/x/net/html/atom"
 )
 
+var (
+	defaultAcceptContentTypes = []string{"text/html"}
+)
+
 // Meta is a model that structure contents of meta tag in html.
 type Meta struct {
 	Property string
@@ -27,6 +31,34 @@ type ParserOpts struct {
 	// You can add body to parse target.
 	// If html have some meta tags in the body, you should set to true.
 	IncludeBody bool
+	// Media types of Content-Type accepted by Parse and Fetch. Wildcard subtypes such as `text/*` can be used.
+	// Default is `text/html`. Responses without Content-Type are always accepted.
+	AcceptContentTypes []string
+	// If it is true, the media type is detected from the body by http.DetectContentType
+	// when Content-Type of the response is not accepted.
+	SniffContentType bool
+	// AcceptStatus returns true when the status code of the response is accepted by Parse and Fetch.
+	// If it is nil, only 200 is accepted.
+	AcceptStatus func(statusCode int) bool
+	// Policy used when a property appears more than once for a single value. Default is ConflictFirst.
+	// It can be overridden for each field by `conflict=${policy}` option of the struct tag.
+	// (e.g. `googp:"og:title,conflict=last"`)
+	ConflictPolicy ConflictPolicy
+	// OnDiagnostic is called when a problem which is not an error is found while parsing.
+	OnDiagnostic func(*Diagnostic)
+	// If it is true, the problems reported to OnDiagnostic are returned as DiagnosticError,
+	// and the struct type is checked by CheckModel before parsing.
+	Strict bool
+	// Maximum number of elements collected into each slice. Default is 0, which means unlimited.
+	// It can be overridden for each field by `max=${n}` option of the struct tag.
+	MaxElements int
+	// Key of the struct tag. Default is `googp`.
+	TagKey string
+	// Namespace of the property names inferred from the names of the fields without the tag. Default is `og`.
+	// (e.g. `twitter` infers `twitter:site` from `Site` field)
+	DefaultNamespace string
+	// If it is true, the fields without the tag are ignored instead of inferring the property names.
+	DisableInference bool
 }
 
 // NewParser create a `Parser`
@@ -52,8 +84,58 @@ func (parser *Parser) Parse(reader io.Reader, i interface{}) error {
 
 // ParseNode is execute to parse OGPs from the HTML node.
 func (parser *Parser) ParseNode(n *html.Node, i interface{}) error {
-	ac := newAccessor(nil, reflect.ValueOf(i))
-	return parser.parseNode(n, ac)
+	ac, err := parser.newAccessor(i)
+	if err != nil {
+		return err
+	}
+	if err := parser.parseNode(n, ac); err != nil {
+		return err
+	}
+	return ac.Finish()
+}
+
+// decoder returns a decoder with the options of the parser.
+func (parser *Parser) decoder() *decoder {
+	return newDecoder(&parser.opts)
+}
+
+// newAccessor returns the accessor of the value. In strict mode, it checks the type of the value by CheckModel.
+func (parser *Parser) newAccessor(i interface{}) (accessor, error) {
+	d := parser.decoder()
+	if parser.opts.Strict {
+		if err := d.checkModel(reflect.TypeOf(i)); err != nil {
+			return nil, err
+		}
+	}
+	return d.newAccessor(nil, reflect.ValueOf(i)), nil
+}
+
+// parseMetas returns the metas parsed from the HTML.
+func (parser *Parser) parseMetas(reader io.Reader) ([]*Meta, error) {
+	node, err := html.Parse(reader)
+	if err != nil {
+		return nil, err
+	}
+
+	recorder := &metaRecorder{}
+	if err := parser.parseNode(node, recorder); err != nil {
+		return nil, err
+	}
+	return recorder.metas, nil
+}
+
+// setMetas writes the metas to the value in order.
+func (parser *Parser) setMetas(metas []*Meta, i interface{}) error {
+	ac, err := parser.newAccessor(i)
+	if err != nil {
+		return err
+	}
+	for _, meta := range metas {
+		if err := ac.Set(meta.Property, meta.Content); err != nil {
+			return err
+		}
+	}
+	return ac.Finish()
 }
 
 func (parser *Parser) parseNode(n *html.Node, ac accessor) error {
@@ -91,6 +173,20 @@ func (parser *Parser) parseChildNode(n *html.Node, ac accessor) error {
 	return nil
 }
 
+// metaRecorder is an accessor that records the metas instead of writing them to variables.
+type metaRecorder struct {
+	metas []*Meta
+}
+
+func (r *metaRecorder) Set(key string, val string) error {
+	r.metas = append(r.metas, &Meta{Property: key, Content: val})
+	return nil
+}
+
+func (r *metaRecorder) Finish() error {
+	return nil
+}
+
 func getOGPMeta(n *html.Node) *Meta {
 	if n.DataAtom != atom.Meta {
 		return nil
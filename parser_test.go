@@ -1,6 +1,7 @@
 package googp
 
 import (
+	"errors"
 	"fmt"
 	"net/http"
 	"os"
@@ -124,6 +125,56 @@ func TestParser_Parse_4(t *testing.T) {
 	assertEqual(t, ogp.Images[0].URL, "https://example.com/image")
 }
 
+func TestParser_Parse_RequiredAndDefault(t *testing.T) {
+	type Model struct {
+		Title string `googp:"og:title,required"`
+		Type  string `googp:"og:type,default=website"`
+		URL   string `googp:"og:url,required"`
+	}
+
+	parser := NewParser()
+	var v Model
+	err := parser.Parse(strings.NewReader(`<html><head><meta property="og:title" content="title" /></head></html>`), &v)
+	var missingErr *MissingRequiredError
+	assertEqual(t, errors.As(err, &missingErr), true)
+	assertEqual(t, missingErr.Properties, []string{"og:url"})
+
+	v = Model{}
+	assertNoError(t, parser.Parse(strings.NewReader(`<html><head>
+		<meta property="og:title" content="title" />
+		<meta property="og:url" content="http://example.com" />
+	</head></html>`), &v))
+	assertEqual(t, v, Model{Title: "title", Type: "website", URL: "http://example.com"})
+}
+
+func TestParser_Parse_TagRules(t *testing.T) {
+	type Card struct {
+		Card    string
+		Site    string
+		Title   string `meta:"og:title" googp:"twitter:title"`
+		Creator string `googp:"-"`
+	}
+	html := `<html><head>
+		<meta property="og:title" content="og title" />
+		<meta property="twitter:title" content="twitter title" />
+		<meta property="twitter:card" content="summary" />
+		<meta property="twitter:site" content="@example" />
+		<meta property="twitter:creator" content="@creator" />
+	</head></html>`
+
+	var card Card
+	assertNoError(t, NewParser(ParserOpts{DefaultNamespace: "twitter"}).Parse(strings.NewReader(html), &card))
+	assertEqual(t, card, Card{Card: "summary", Site: "@example", Title: "twitter title"})
+
+	card = Card{}
+	assertNoError(t, NewParser(ParserOpts{TagKey: "meta", DefaultNamespace: "twitter"}).Parse(strings.NewReader(html), &card))
+	assertEqual(t, card, Card{Card: "summary", Site: "@example", Title: "og title", Creator: "@creator"})
+
+	card = Card{}
+	assertNoError(t, NewParser(ParserOpts{DisableInference: true}).Parse(strings.NewReader(html), &card))
+	assertEqual(t, card, Card{Title: "twitter title"})
+}
+
 func ExampleParser_Parse() {
 	reader := strings.NewReader(`
 		<html>
@@ -0,0 +1,240 @@
+package googp
+
+import (
+	"fmt"
+	"net"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"syscall"
+	"time"
+)
+
+var (
+	defaultAllowedSchemes = []string{"http", "https"}
+	defaultAllowedPorts   = []int{80, 443}
+	defaultDeniedNetworks = mustParseCIDRs(
+		"0.0.0.0/8",       // "This" network
+		"10.0.0.0/8",      // Private-Use
+		"100.64.0.0/10",   // Shared Address Space
+		"127.0.0.0/8",     // Loopback
+		"169.254.0.0/16",  // Link Local
+		"172.16.0.0/12",   // Private-Use
+		"192.0.0.0/24",    // IETF Protocol Assignments
+		"192.0.2.0/24",    // Documentation (TEST-NET-1)
+		"192.168.0.0/16",  // Private-Use
+		"198.18.0.0/15",   // Benchmarking
+		"198.51.100.0/24", // Documentation (TEST-NET-2)
+		"203.0.113.0/24",  // Documentation (TEST-NET-3)
+		"224.0.0.0/4",     // Multicast
+		"240.0.0.0/4",     // Reserved and Limited Broadcast
+		"::/128",          // Unspecified Address
+		"::1/128",         // Loopback Address
+		"64:ff9b::/96",    // IPv4-IPv6 Translation (Well-Known Prefix)
+		"64:ff9b:1::/48",  // IPv4-IPv6 Translation (Local-Use)
+		"100::/64",        // Discard-Only Address Block
+		"2001:db8::/32",   // Documentation
+		"2002::/16",       // 6to4
+		"fc00::/7",        // Unique-Local
+		"fe80::/10",       // Link-Local Unicast
+		"ff00::/8",        // Multicast
+	)
+)
+
+// NetworkPolicy is a policy that restricts the destinations of requests.
+// It is checked for every request including redirects, and the IP address is checked when connecting,
+// so it cannot be bypassed by DNS rebinding.
+//
+// By default, only http and https on port 80 and 443 are allowed,
+// and private, loopback, link-local and other special-purpose addresses are denied.
+type NetworkPolicy struct {
+	// Allowed URL schemes. Default is http and https.
+	AllowedSchemes []string
+	// Allowed ports. Default is 80 and 443.
+	AllowedPorts []int
+	// Networks that are allowed even if they are denied.
+	AllowedNetworks []*net.IPNet
+	// Networks that are denied in addition to the default ones.
+	DeniedNetworks []*net.IPNet
+}
+
+// DestinationError is an error returned when the destination is not allowed by NetworkPolicy.
+type DestinationError struct {
+	// Destination is the URL or the address which is not allowed.
+	Destination string
+	Reason      string
+}
+
+func (err *DestinationError) Error() string {
+	return fmt.Sprintf("Destination is not allowed (%s): %s", err.Destination, err.Reason)
+}
+
+// policyTransport is a http.RoundTripper that checks the URL of each request.
+type policyTransport struct {
+	policy    *NetworkPolicy
+	transport http.RoundTripper
+}
+
+// NewClient returns a copy of the client that restricts the destinations by the policy.
+// If base is nil, http.DefaultClient is used.
+// It panics when the transport of the client is not `*http.Transport`.
+//
+// Proxies and the custom dial functions of the transport are not used by the returned client,
+// because the destinations cannot be checked through them.
+func (p *NetworkPolicy) NewClient(base *http.Client) *http.Client {
+	if base == nil {
+		base = http.DefaultClient
+	}
+
+	var transport *http.Transport
+	switch t := base.Transport.(type) {
+	case nil:
+		transport = http.DefaultTransport.(*http.Transport).Clone()
+	case *http.Transport:
+		transport = t.Clone()
+	default:
+		panic("NetworkPolicy requires *http.Transport")
+	}
+
+	dialer := &net.Dialer{
+		Timeout:   30 * time.Second,
+		KeepAlive: 30 * time.Second,
+		Control: func(network, address string, _ syscall.RawConn) error {
+			return p.checkAddr(address)
+		},
+	}
+	transport.Proxy = nil
+	transport.DialContext = dialer.DialContext
+	// NOTE: DialTLS and DialTLSContext take precedence over DialContext for https, so they bypass the check.
+	transport.DialTLS = nil
+	transport.DialTLSContext = nil
+
+	client := *base
+	client.Transport = &policyTransport{policy: p, transport: transport}
+	return &client
+}
+
+func (t *policyTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	if err := t.policy.checkURL(req.URL); err != nil {
+		if req.Body != nil {
+			req.Body.Close()
+		}
+		return nil, err
+	}
+	return t.transport.RoundTrip(req)
+}
+
+// checkURL checks the scheme and the port of the URL, and the host when it is an IP address.
+func (p *NetworkPolicy) checkURL(u *url.URL) error {
+	schemes := p.AllowedSchemes
+	if len(schemes) == 0 {
+		schemes = defaultAllowedSchemes
+	}
+	if !containsFold(schemes, u.Scheme) {
+		return &DestinationError{Destination: u.String(), Reason: "scheme " + u.Scheme + " is not allowed"}
+	}
+
+	port := u.Port()
+	if port == "" {
+		switch strings.ToLower(u.Scheme) {
+		case "http":
+			port = "80"
+		case "https":
+			port = "443"
+		}
+	}
+	if err := p.checkPort(port); err != nil {
+		err.Destination = u.String()
+		return err
+	}
+
+	if ip := net.ParseIP(u.Hostname()); ip != nil {
+		if err := p.checkIP(ip); err != nil {
+			err.Destination = u.String()
+			return err
+		}
+	}
+	return nil
+}
+
+// checkAddr checks the resolved address when connecting.
+func (p *NetworkPolicy) checkAddr(address string) error {
+	host, port, err := net.SplitHostPort(address)
+	if err != nil {
+		return &DestinationError{Destination: address, Reason: err.Error()}
+	}
+
+	ip := net.ParseIP(host)
+	if ip == nil {
+		return &DestinationError{Destination: address, Reason: "address is not an IP address"}
+	}
+	if err := p.checkIP(ip); err != nil {
+		err.Destination = address
+		return err
+	}
+	if err := p.checkPort(port); err != nil {
+		err.Destination = address
+		return err
+	}
+	return nil
+}
+
+func (p *NetworkPolicy) checkPort(port string) *DestinationError {
+	ports := p.AllowedPorts
+	if len(ports) == 0 {
+		ports = defaultAllowedPorts
+	}
+
+	n, err := strconv.Atoi(port)
+	if err == nil {
+		for _, allowed := range ports {
+			if n == allowed {
+				return nil
+			}
+		}
+	}
+	return &DestinationError{Reason: "port " + port + " is not allowed"}
+}
+
+func (p *NetworkPolicy) checkIP(ip net.IP) *DestinationError {
+	// NOTE: IPv4-mapped IPv6 addresses are checked as IPv4 addresses.
+	if ip4 := ip.To4(); ip4 != nil {
+		ip = ip4
+	}
+
+	for _, network := range p.AllowedNetworks {
+		if network.Contains(ip) {
+			return nil
+		}
+	}
+	for _, networks := range [][]*net.IPNet{defaultDeniedNetworks, p.DeniedNetworks} {
+		for _, network := range networks {
+			if network.Contains(ip) {
+				return &DestinationError{Reason: "address " + ip.String() + " is in the denied network " + network.String()}
+			}
+		}
+	}
+	return nil
+}
+
+func containsFold(list []string, s string) bool {
+	for _, v := range list {
+		if strings.EqualFold(v, s) {
+			return true
+		}
+	}
+	return false
+}
+
+func mustParseCIDRs(cidrs ...string) []*net.IPNet {
+	networks := make([]*net.IPNet, len(cidrs))
+	for i, cidr := range cidrs {
+		_, network, err := net.ParseCIDR(cidr)
+		if err != nil {
+			panic(err)
+		}
+		networks[i] = network
+	}
+	return networks
+}
@@ -0,0 +1,173 @@
+package googp
+
+import (
+	"context"
+	"errors"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strconv"
+	"strings"
+	"sync/atomic"
+	"testing"
+)
+
+func TestFetcher_Fetch_NetworkPolicy(t *testing.T) {
+	server := httptest.NewServer(http.FileServer(http.Dir("data")))
+	defer server.Close()
+
+	u, _ := url.Parse(server.URL)
+	port, _ := strconv.Atoi(u.Port())
+
+	// Loopback address is denied by default.
+	fetcher := NewFetcher(FetcherOpts{NetworkPolicy: &NetworkPolicy{AllowedPorts: []int{port}}})
+	var ogp OGP
+	var destErr *DestinationError
+	assertEqual(t, errors.As(fetcher.Fetch(server.URL+"/1.html", &ogp), &destErr), true)
+
+	// It is checked when connecting, even if the host is not an IP address.
+	destErr = nil
+	assertEqual(t, errors.As(fetcher.Fetch("http://localhost:"+u.Port()+"/1.html", &ogp), &destErr), true)
+	assertEqual(t, strings.HasSuffix(destErr.Destination, ":"+u.Port()), true)
+
+	// The port is not allowed.
+	fetcher = NewFetcher(FetcherOpts{NetworkPolicy: &NetworkPolicy{AllowedNetworks: mustParseCIDRs("127.0.0.0/8")}})
+	destErr = nil
+	assertEqual(t, errors.As(fetcher.Fetch(server.URL+"/1.html", &ogp), &destErr), true)
+	assertEqual(t, destErr.Reason, "port "+u.Port()+" is not allowed")
+
+	// The scheme is not allowed.
+	destErr = nil
+	assertEqual(t, errors.As(fetcher.Fetch("ftp://127.0.0.1/1.html", &ogp), &destErr), true)
+	assertEqual(t, destErr.Reason, "scheme ftp is not allowed")
+
+	fetcher = NewFetcher(FetcherOpts{NetworkPolicy: &NetworkPolicy{
+		AllowedPorts:    []int{port},
+		AllowedNetworks: mustParseCIDRs("127.0.0.0/8"),
+	}})
+	assertNoError(t, fetcher.Fetch(server.URL+"/1.html", &ogp))
+	assertEqual(t, ogp.Title, "title")
+}
+
+func TestFetcher_Fetch_NetworkPolicyRedirect(t *testing.T) {
+	internal := httptest.NewServer(http.FileServer(http.Dir("data")))
+	defer internal.Close()
+	server := httptest.NewServer(http.RedirectHandler(internal.URL+"/1.html", http.StatusFound))
+	defer server.Close()
+
+	u, _ := url.Parse(server.URL)
+	port, _ := strconv.Atoi(u.Port())
+
+	fetcher := NewFetcher(FetcherOpts{NetworkPolicy: &NetworkPolicy{
+		AllowedPorts:    []int{port},
+		AllowedNetworks: mustParseCIDRs("127.0.0.0/8"),
+	}})
+	var ogp OGP
+	var destErr *DestinationError
+	assertEqual(t, errors.As(fetcher.Fetch(server.URL, &ogp), &destErr), true)
+	assertEqual(t, destErr.Destination, internal.URL+"/1.html")
+}
+
+func TestFetcher_Fetch_NetworkPolicyRobots(t *testing.T) {
+	var robotsCount int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/robots.txt" {
+			atomic.AddInt32(&robotsCount, 1)
+			return
+		}
+		http.ServeFile(w, r, "data/1.html")
+	}))
+	defer server.Close()
+
+	u, _ := url.Parse(server.URL)
+	port, _ := strconv.Atoi(u.Port())
+
+	// robots.txt is not requested when the destination is denied.
+	fetcher := NewFetcher(FetcherOpts{
+		Robots:        NewRobots(),
+		NetworkPolicy: &NetworkPolicy{AllowedPorts: []int{port}},
+	})
+	var ogp OGP
+	var destErr *DestinationError
+	assertEqual(t, errors.As(fetcher.Fetch(server.URL+"/", &ogp), &destErr), true)
+	assertEqual(t, errors.As(fetcher.Fetch("http://localhost:"+u.Port()+"/", &ogp), &destErr), true)
+	assertEqual(t, atomic.LoadInt32(&robotsCount), int32(0))
+
+	fetcher = NewFetcher(FetcherOpts{
+		Robots: NewRobots(),
+		NetworkPolicy: &NetworkPolicy{
+			AllowedPorts:    []int{port},
+			AllowedNetworks: mustParseCIDRs("127.0.0.0/8"),
+		},
+	})
+	assertNoError(t, fetcher.Fetch(server.URL+"/", &ogp))
+	assertEqual(t, atomic.LoadInt32(&robotsCount), int32(1))
+}
+
+func TestNetworkPolicy_NewClient(t *testing.T) {
+	policy := &NetworkPolicy{}
+	client := policy.NewClient(nil)
+	assertNotEqual(t, client, http.DefaultClient)
+	_, err := client.Get("http://169.254.169.254/latest/meta-data/")
+	var destErr *DestinationError
+	assertEqual(t, errors.As(err, &destErr), true)
+
+	defer func() {
+		assertNotEqual(t, recover(), nil)
+	}()
+	policy.NewClient(&http.Client{Transport: roundTripperFunc(http.DefaultTransport.RoundTrip)})
+}
+
+func TestNetworkPolicy_NewClientDialTLS(t *testing.T) {
+	dialed := false
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+	transport.DialTLSContext = func(ctx context.Context, network, addr string) (net.Conn, error) {
+		dialed = true
+		return nil, errors.New("dialed")
+	}
+
+	client := (&NetworkPolicy{}).NewClient(&http.Client{Transport: transport})
+	_, err := client.Get("https://localhost/")
+	var destErr *DestinationError
+	assertEqual(t, errors.As(err, &destErr), true)
+	assertEqual(t, dialed, false)
+}
+
+func TestNetworkPolicy_CheckIP(t *testing.T) {
+	policy := &NetworkPolicy{DeniedNetworks: mustParseCIDRs("8.8.8.0/24")}
+	for _, addr := range []string{
+		"127.0.0.1",
+		"10.1.2.3",
+		"172.16.0.1",
+		"192.168.1.1",
+		"169.254.169.254",
+		"100.64.0.1",
+		"0.0.0.0",
+		"255.255.255.255",
+		"::1",
+		"::",
+		"fe80::1",
+		"fd00::1",
+		"::ffff:127.0.0.1",
+		"::ffff:10.0.0.1",
+		"64:ff9b::7f00:1",
+		"64:ff9b::a00:1",
+		"2002:7f00:1::1",
+		"2002:c0a8:101::1",
+		"8.8.8.8",
+	} {
+		if policy.checkIP(net.ParseIP(addr)) == nil {
+			t.Errorf("%s must be denied", addr)
+		}
+	}
+	for _, addr := range []string{
+		"1.1.1.1",
+		"93.184.216.34",
+		"2606:2800:220:1:248:1893:25c8:1946",
+	} {
+		if err := policy.checkIP(net.ParseIP(addr)); err != nil {
+			t.Errorf("%s must be allowed: %s", addr, err)
+		}
+	}
+}
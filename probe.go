@@ -0,0 +1,231 @@
+package googp
+
+import (
+	"bytes"
+	"context"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"image"
+	"image/gif"
+	"image/jpeg"
+	"image/png"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"sync"
+)
+
+const (
+	defaultProbeMaxBytes    = 64 * 1024
+	defaultProbeConcurrency = 4
+)
+
+var (
+	// ErrUnknownImageFormat is an error returned when the format of the image cannot be detected.
+	ErrUnknownImageFormat = errors.New("Unknown image format")
+)
+
+// ProbeOpts is an option of ProbeImages.
+type ProbeOpts struct {
+	// HTTP client used to get the images. If it is nil, http.DefaultClient is used.
+	Client *http.Client
+	// Maximum number of bytes read from each image. Default is 64KiB.
+	MaxBytes int64
+	// Maximum number of images fetched at the same time. Default is 4.
+	Concurrency int
+}
+
+// ImageProbeError is an error returned when the image could not be probed in ProbeImages.
+type ImageProbeError struct {
+	URL string
+	Err error
+}
+
+func (err *ImageProbeError) Error() string {
+	return fmt.Sprintf("Failed to probe the image (%s): %s", err.URL, err.Err)
+}
+
+func (err *ImageProbeError) Unwrap() error {
+	return err.Err
+}
+
+// ProbeImages fetches the header bytes of each image and fills in Width, Height and Type when they are absent.
+// PNG, JPEG, GIF and WebP are supported.
+//
+// All images are probed even if some of them fail, and the first error in order of images is returned.
+func ProbeImages(ctx context.Context, images []Image, opts ...ProbeOpts) error {
+	var opt ProbeOpts
+	switch len(opts) {
+	case 0:
+	case 1:
+		opt = opts[0]
+	default:
+		panic("Cannot specify multiple ProbeOpts")
+	}
+	if opt.Client == nil {
+		opt.Client = http.DefaultClient
+	}
+	if opt.MaxBytes <= 0 {
+		opt.MaxBytes = defaultProbeMaxBytes
+	}
+	if opt.Concurrency <= 0 {
+		opt.Concurrency = defaultProbeConcurrency
+	}
+
+	errs := make([]error, len(images))
+	sem := make(chan struct{}, opt.Concurrency)
+	var wg sync.WaitGroup
+
+loop:
+	for i := range images {
+		img := &images[i]
+		if img.Width != 0 && img.Height != 0 && img.Type != "" {
+			continue
+		}
+		rawurl := img.URL
+		if rawurl == "" {
+			rawurl = img.SecureURL
+		}
+		if rawurl == "" {
+			continue
+		}
+
+		select {
+		case sem <- struct{}{}:
+		case <-ctx.Done():
+			break loop
+		}
+
+		wg.Add(1)
+		go func(i int, img *Image, rawurl string) {
+			defer wg.Done()
+			defer func() { <-sem }()
+
+			if err := probeImage(ctx, &opt, img, rawurl); err != nil {
+				errs[i] = &ImageProbeError{URL: rawurl, Err: err}
+			}
+		}(i, img, rawurl)
+	}
+	wg.Wait()
+
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	for _, err := range errs {
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func probeImage(ctx context.Context, opt *ProbeOpts, img *Image, rawurl string) error {
+	req, err := http.NewRequest("GET", rawurl, nil)
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Range", fmt.Sprintf("bytes=0-%d", opt.MaxBytes-1))
+
+	res, err := opt.Client.Do(req.WithContext(ctx))
+	if err != nil {
+		return err
+	}
+	defer res.Body.Close()
+
+	if res.StatusCode != http.StatusOK && res.StatusCode != http.StatusPartialContent {
+		return newBadStatusCodeError(res)
+	}
+
+	data, err := ioutil.ReadAll(io.LimitReader(res.Body, opt.MaxBytes))
+	if err != nil {
+		return err
+	}
+
+	mimeType, cfg, err := decodeImageConfig(data)
+	if err != nil {
+		return err
+	}
+
+	if img.Width == 0 {
+		img.Width = cfg.Width
+	}
+	if img.Height == 0 {
+		img.Height = cfg.Height
+	}
+	if img.Type == "" {
+		img.Type = mimeType
+	}
+	return nil
+}
+
+// decodeImageConfig returns the MIME type and the dimensions of the image from the header bytes.
+func decodeImageConfig(data []byte) (string, image.Config, error) {
+	var (
+		mimeType string
+		cfg      image.Config
+		err      error
+	)
+
+	switch {
+	case bytes.HasPrefix(data, []byte("\x89PNG\r\n\x1a\n")):
+		mimeType = "image/png"
+		cfg, err = png.DecodeConfig(bytes.NewReader(data))
+	case bytes.HasPrefix(data, []byte("\xff\xd8")):
+		mimeType = "image/jpeg"
+		cfg, err = jpeg.DecodeConfig(bytes.NewReader(data))
+	case bytes.HasPrefix(data, []byte("GIF87a")), bytes.HasPrefix(data, []byte("GIF89a")):
+		mimeType = "image/gif"
+		cfg, err = gif.DecodeConfig(bytes.NewReader(data))
+	case len(data) >= 12 && string(data[0:4]) == "RIFF" && string(data[8:12]) == "WEBP":
+		mimeType = "image/webp"
+		cfg, err = decodeWebPConfig(data[12:])
+	default:
+		return "", cfg, ErrUnknownImageFormat
+	}
+	return mimeType, cfg, err
+}
+
+// decodeWebPConfig returns the dimensions of the WebP image from the first chunk.
+// ref: https://developers.google.com/speed/webp/docs/riff_container
+func decodeWebPConfig(data []byte) (image.Config, error) {
+	var cfg image.Config
+	if len(data) < 8 {
+		return cfg, io.ErrUnexpectedEOF
+	}
+	chunk, data := string(data[0:4]), data[8:]
+
+	switch chunk {
+	case "VP8 ":
+		// 3 bytes frame tag, 3 bytes start code, and 14 bits width and height.
+		if len(data) < 10 {
+			return cfg, io.ErrUnexpectedEOF
+		}
+		if !bytes.Equal(data[3:6], []byte{0x9d, 0x01, 0x2a}) {
+			return cfg, ErrUnknownImageFormat
+		}
+		cfg.Width = int(binary.LittleEndian.Uint16(data[6:8]) & 0x3fff)
+		cfg.Height = int(binary.LittleEndian.Uint16(data[8:10]) & 0x3fff)
+	case "VP8L":
+		// 1 byte signature, and 14 bits (width - 1) and (height - 1).
+		if len(data) < 5 {
+			return cfg, io.ErrUnexpectedEOF
+		}
+		if data[0] != 0x2f {
+			return cfg, ErrUnknownImageFormat
+		}
+		bits := binary.LittleEndian.Uint32(data[1:5])
+		cfg.Width = int(bits&0x3fff) + 1
+		cfg.Height = int((bits>>14)&0x3fff) + 1
+	case "VP8X":
+		// 4 bytes flags, and 24 bits (width - 1) and (height - 1).
+		if len(data) < 10 {
+			return cfg, io.ErrUnexpectedEOF
+		}
+		cfg.Width = int(uint32(data[4])|uint32(data[5])<<8|uint32(data[6])<<16) + 1
+		cfg.Height = int(uint32(data[7])|uint32(data[8])<<8|uint32(data[9])<<16) + 1
+	default:
+		return cfg, ErrUnknownImageFormat
+	}
+	return cfg, nil
+}
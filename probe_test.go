@@ -0,0 +1,164 @@
+package googp
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestProbeImages(t *testing.T) {
+	server := httptest.NewServer(http.FileServer(http.Dir("data")))
+	defer server.Close()
+
+	images := []Image{
+		{URL: server.URL + "/image.png"},
+		{URL: server.URL + "/image.jpg"},
+		{URL: server.URL + "/image.gif"},
+		{SecureURL: server.URL + "/image.webp"},
+	}
+	assertNoError(t, ProbeImages(context.Background(), images))
+
+	assertEqual(t, images[0], Image{URL: server.URL + "/image.png", Type: "image/png", Width: 3, Height: 2})
+	assertEqual(t, images[1], Image{URL: server.URL + "/image.jpg", Type: "image/jpeg", Width: 4, Height: 3})
+	assertEqual(t, images[2], Image{URL: server.URL + "/image.gif", Type: "image/gif", Width: 5, Height: 4})
+	assertEqual(t, images[3], Image{SecureURL: server.URL + "/image.webp", Type: "image/webp", Width: 6, Height: 5})
+}
+
+func TestProbeImages_Present(t *testing.T) {
+	var requested bool
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requested = true
+		http.ServeFile(w, r, "data/image.png")
+	}))
+	defer server.Close()
+
+	images := []Image{
+		{URL: server.URL, Type: "image/x-png", Width: 100, Height: 200},
+	}
+	assertNoError(t, ProbeImages(context.Background(), images))
+	assertEqual(t, requested, false)
+
+	images[0].Width = 0
+	assertNoError(t, ProbeImages(context.Background(), images))
+	assertEqual(t, requested, true)
+	// Only absent values are filled in.
+	assertEqual(t, images[0], Image{URL: server.URL, Type: "image/x-png", Width: 3, Height: 200})
+}
+
+func TestProbeImages_MaxBytes(t *testing.T) {
+	var rangeHeader string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		rangeHeader = r.Header.Get("Range")
+		http.ServeFile(w, r, "data/image.jpg")
+	}))
+	defer server.Close()
+
+	images := []Image{{URL: server.URL}}
+	err := ProbeImages(context.Background(), images, ProbeOpts{MaxBytes: 16})
+	assertEqual(t, rangeHeader, "bytes=0-15")
+	assertError(t, err)
+
+	var probeErr *ImageProbeError
+	assertEqual(t, errors.As(err, &probeErr), true)
+	assertEqual(t, probeErr.URL, server.URL)
+	assertEqual(t, images[0], Image{URL: server.URL})
+}
+
+func TestProbeImages_Error(t *testing.T) {
+	server := httptest.NewServer(http.FileServer(http.Dir("data")))
+	defer server.Close()
+
+	images := []Image{
+		{URL: server.URL + "/image.png"},
+		{URL: server.URL + "/notfound.png"},
+		{URL: server.URL + "/1.html"},
+	}
+	err := ProbeImages(context.Background(), images)
+	assertError(t, err)
+
+	var statusErr *BadStatusCodeError
+	assertEqual(t, errors.As(err, &statusErr), true)
+	assertEqual(t, statusErr.StatusCode, 404)
+	// The other images are probed even if some of them fail.
+	assertEqual(t, images[0].Width, 3)
+
+	images = []Image{{URL: server.URL + "/1.html"}}
+	assertEqual(t, errors.Is(ProbeImages(context.Background(), images), ErrUnknownImageFormat), true)
+}
+
+func TestProbeImages_Concurrency(t *testing.T) {
+	var (
+		mu      sync.Mutex
+		running int
+		maximum int
+	)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		running++
+		if running > maximum {
+			maximum = running
+		}
+		mu.Unlock()
+
+		time.Sleep(20 * time.Millisecond)
+		http.ServeFile(w, r, "data/image.gif")
+
+		mu.Lock()
+		running--
+		mu.Unlock()
+	}))
+	defer server.Close()
+
+	images := make([]Image, 8)
+	for i := range images {
+		images[i].URL = server.URL
+	}
+	assertNoError(t, ProbeImages(context.Background(), images, ProbeOpts{Concurrency: 2}))
+	assertEqual(t, maximum, 2)
+	for _, img := range images {
+		assertEqual(t, img.Width, 5)
+	}
+}
+
+func TestProbeImages_Cancel(t *testing.T) {
+	done := make(chan struct{})
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		select {
+		case <-done:
+		case <-r.Context().Done():
+		}
+	}))
+	defer server.Close()
+	defer close(done)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+
+	images := []Image{{URL: server.URL}, {URL: server.URL}}
+	err := ProbeImages(ctx, images, ProbeOpts{Concurrency: 1})
+	assertEqual(t, errors.Is(err, context.DeadlineExceeded), true)
+}
+
+func Test_DecodeWebPConfig(t *testing.T) {
+	// Lossy
+	cfg, err := decodeWebPConfig([]byte("VP8 \x0a\x00\x00\x00\x00\x00\x00\x9d\x01\x2a\x40\x01\xf0\x00"))
+	assertNoError(t, err)
+	assertEqual(t, cfg.Width, 320)
+	assertEqual(t, cfg.Height, 240)
+
+	// Extended
+	cfg, err = decodeWebPConfig([]byte("VP8X\x0a\x00\x00\x00\x10\x00\x00\x00\x3f\x01\x00\xef\x00\x00"))
+	assertNoError(t, err)
+	assertEqual(t, cfg.Width, 320)
+	assertEqual(t, cfg.Height, 240)
+
+	_, err = decodeWebPConfig([]byte("VP8L\x05\x00\x00\x00\x2f"))
+	assertError(t, err)
+
+	_, err = decodeWebPConfig([]byte("ALPH\x00\x00\x00\x00"))
+	assertEqual(t, err, ErrUnknownImageFormat)
+}
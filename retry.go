@@ -0,0 +1,180 @@
+package googp
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"math/rand"
+	"net"
+	"net/http"
+	"strconv"
+	"syscall"
+	"time"
+)
+
+const (
+	defaultRetryMaxAttempts = 3
+	defaultRetryBaseDelay   = 500 * time.Millisecond
+	defaultRetryMaxDelay    = 30 * time.Second
+)
+
+// RetryPolicy is a policy to retry the request when it fails transiently.
+type RetryPolicy struct {
+	// Maximum number of attempts including the first one. Default is 3.
+	MaxAttempts int
+	// Delay before the first retry. It is doubled for each retry, and randomized by jitter. Default is 500ms.
+	BaseDelay time.Duration
+	// Maximum delay between the attempts. Default is 30s.
+	// When the server requests to wait longer than it with `Retry-After`, it does not retry.
+	MaxDelay time.Duration
+	// Retryable returns true when the request should be retried.
+	// Either res or err is nil. If it is nil, IsRetryable is used.
+	Retryable func(res *http.Response, err error) bool
+}
+
+// RetryError is an error returned when the request fails after it is retried,
+// or when it gives up retrying because of `Retry-After`.
+type RetryError struct {
+	// Number of attempts.
+	Attempts int
+	// The error of the last attempt.
+	Err error
+}
+
+func (err *RetryError) Error() string {
+	return fmt.Sprintf("Failed after %d attempts: %s", err.Attempts, err.Err)
+}
+
+func (err *RetryError) Unwrap() error {
+	return err.Err
+}
+
+// IsRetryable returns true when the response or the error is transient.
+// Timeouts, refused or reset connections, connections closed unexpectedly,
+// and the status codes 408, 429, 500, 502, 503 and 504 are regarded as transient.
+//
+// Other errors (e.g. DestinationError, ErrRedirectLoop, certificate errors and invalid URLs) are not retried.
+func IsRetryable(res *http.Response, err error) bool {
+	if err != nil {
+		return isTransientError(err)
+	}
+
+	switch res.StatusCode {
+	case http.StatusRequestTimeout,
+		http.StatusTooManyRequests,
+		http.StatusInternalServerError,
+		http.StatusBadGateway,
+		http.StatusServiceUnavailable,
+		http.StatusGatewayTimeout:
+		return true
+	}
+	return false
+}
+
+// isTransientError returns true when the error may not occur in the next attempt.
+func isTransientError(err error) bool {
+	var destErr *DestinationError
+	if errors.Is(err, context.Canceled) || errors.As(err, &destErr) {
+		return false
+	}
+	if errors.Is(err, syscall.ECONNREFUSED) ||
+		errors.Is(err, syscall.ECONNRESET) ||
+		errors.Is(err, io.EOF) ||
+		errors.Is(err, io.ErrUnexpectedEOF) {
+		return true
+	}
+	// NOTE: *url.Error returned from http.Client is also a net.Error.
+	var netErr net.Error
+	return errors.As(err, &netErr) && netErr.Timeout()
+}
+
+// do sends the request with retrying according to the policy, and returns the number of attempts.
+// It returns a `*RetryError` when the request fails after it is retried, or with retryable errors in all attempts.
+func (p *RetryPolicy) do(client *http.Client, req *http.Request) (*http.Response, int, error) {
+	retryable := p.Retryable
+	if retryable == nil {
+		retryable = IsRetryable
+	}
+
+	for attempt := 1; ; attempt++ {
+		res, err := client.Do(req)
+		// NOTE: It does not retry when the context of the request is done.
+		if !retryable(res, err) || (err != nil && req.Context().Err() != nil) {
+			if err != nil && attempt > 1 {
+				err = &RetryError{Attempts: attempt, Err: err}
+			}
+			return res, attempt, err
+		}
+
+		var delay time.Duration
+		if err == nil {
+			err = newBadStatusCodeError(res)
+			delay = retryAfter(res.Header, time.Now())
+			res.Body.Close()
+		}
+
+		if attempt >= p.maxAttempts() || delay > p.maxDelay() {
+			return nil, attempt, &RetryError{Attempts: attempt, Err: err}
+		}
+		if delay <= 0 {
+			delay = p.backoff(attempt)
+		}
+
+		timer := time.NewTimer(delay)
+		select {
+		case <-timer.C:
+		case <-req.Context().Done():
+			timer.Stop()
+			return nil, attempt, &RetryError{Attempts: attempt, Err: req.Context().Err()}
+		}
+	}
+}
+
+// backoff returns the delay before the next attempt.
+// It is exponential backoff with jitter, which is between the half and the whole of the delay.
+func (p *RetryPolicy) backoff(attempt int) time.Duration {
+	delay := p.BaseDelay
+	if delay <= 0 {
+		delay = defaultRetryBaseDelay
+	}
+	for i := 1; i < attempt && delay < p.maxDelay(); i++ {
+		delay *= 2
+	}
+	if delay > p.maxDelay() {
+		delay = p.maxDelay()
+	}
+
+	half := int64(delay / 2)
+	return time.Duration(half + rand.Int63n(half+1))
+}
+
+func (p *RetryPolicy) maxAttempts() int {
+	if p.MaxAttempts > 0 {
+		return p.MaxAttempts
+	}
+	return defaultRetryMaxAttempts
+}
+
+func (p *RetryPolicy) maxDelay() time.Duration {
+	if p.MaxDelay > 0 {
+		return p.MaxDelay
+	}
+	return defaultRetryMaxDelay
+}
+
+// retryAfter returns the delay specified in `Retry-After` header.
+// It returns 0 when the header is absent or invalid.
+func retryAfter(header http.Header, now time.Time) time.Duration {
+	value := header.Get("Retry-After")
+	if value == "" {
+		return 0
+	}
+	if sec, err := strconv.Atoi(value); err == nil && sec >= 0 {
+		return time.Duration(sec) * time.Second
+	}
+	if t, err := http.ParseTime(value); err == nil {
+		return t.Sub(now)
+	}
+	return 0
+}
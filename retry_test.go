@@ -0,0 +1,225 @@
+package googp
+
+import (
+	"context"
+	"crypto/x509"
+	"errors"
+	"io"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"os"
+	"sync/atomic"
+	"syscall"
+	"testing"
+	"time"
+)
+
+// flakyServer returns the status codes in order, and then serves the content.
+func flakyServer(statuses ...int) (*httptest.Server, *int32) {
+	var count int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		n := atomic.AddInt32(&count, 1)
+		if int(n) <= len(statuses) {
+			if statuses[n-1] == http.StatusTooManyRequests {
+				w.Header().Set("Retry-After", "0")
+			}
+			w.WriteHeader(statuses[n-1])
+			return
+		}
+		http.ServeFile(w, r, "data/1.html")
+	}))
+	return server, &count
+}
+
+func TestFetcher_Fetch_Retry(t *testing.T) {
+	server, count := flakyServer(503, 429, 500)
+	defer server.Close()
+
+	fetcher := NewFetcher(FetcherOpts{Retry: &RetryPolicy{MaxAttempts: 4, BaseDelay: time.Millisecond}})
+	var ogp OGP
+	assertNoError(t, fetcher.Fetch(server.URL, &ogp))
+	assertEqual(t, ogp.Title, "title")
+	assertEqual(t, atomic.LoadInt32(count), int32(4))
+}
+
+func TestFetcher_Fetch_RetryDefault(t *testing.T) {
+	server, count := flakyServer(503, 503)
+	defer server.Close()
+
+	fetcher := NewFetcher(FetcherOpts{Retry: &RetryPolicy{BaseDelay: time.Millisecond}})
+	var ogp OGP
+	assertNoError(t, fetcher.Fetch(server.URL, &ogp))
+	assertEqual(t, ogp.Title, "title")
+	assertEqual(t, atomic.LoadInt32(count), int32(3))
+}
+
+func TestFetcher_Fetch_RetryExhausted(t *testing.T) {
+	server, count := flakyServer(503, 503, 503)
+	defer server.Close()
+
+	fetcher := NewFetcher(FetcherOpts{Retry: &RetryPolicy{MaxAttempts: 2, BaseDelay: time.Millisecond}})
+	var ogp OGP
+	err := fetcher.Fetch(server.URL, &ogp)
+
+	var retryErr *RetryError
+	assertEqual(t, errors.As(err, &retryErr), true)
+	assertEqual(t, retryErr.Attempts, 2)
+
+	var statusErr *BadStatusCodeError
+	assertEqual(t, errors.As(err, &statusErr), true)
+	assertEqual(t, statusErr.StatusCode, 503)
+	assertEqual(t, atomic.LoadInt32(count), int32(2))
+}
+
+func TestFetcher_Fetch_RetryNotRetryable(t *testing.T) {
+	server, count := flakyServer(404)
+	defer server.Close()
+
+	fetcher := NewFetcher(FetcherOpts{Retry: &RetryPolicy{MaxAttempts: 3, BaseDelay: time.Millisecond}})
+	var ogp OGP
+	err := fetcher.Fetch(server.URL, &ogp)
+
+	var retryErr *RetryError
+	assertEqual(t, errors.As(err, &retryErr), false)
+	var statusErr *BadStatusCodeError
+	assertEqual(t, errors.As(err, &statusErr), true)
+	assertEqual(t, statusErr.StatusCode, 404)
+	assertEqual(t, atomic.LoadInt32(count), int32(1))
+
+	// Retryable can be customized.
+	server, count = flakyServer(404)
+	defer server.Close()
+
+	fetcher = NewFetcher(FetcherOpts{Retry: &RetryPolicy{
+		MaxAttempts: 3,
+		BaseDelay:   time.Millisecond,
+		Retryable: func(res *http.Response, err error) bool {
+			return err == nil && res.StatusCode == 404
+		},
+	}})
+	assertNoError(t, fetcher.Fetch(server.URL, &ogp))
+	assertEqual(t, atomic.LoadInt32(count), int32(2))
+}
+
+func TestFetcher_Fetch_RetryThenFail(t *testing.T) {
+	server, count := flakyServer(503, 404)
+	defer server.Close()
+
+	fetcher := NewFetcher(FetcherOpts{Retry: &RetryPolicy{MaxAttempts: 3, BaseDelay: time.Millisecond}})
+	var ogp OGP
+	err := fetcher.Fetch(server.URL, &ogp)
+
+	var retryErr *RetryError
+	assertEqual(t, errors.As(err, &retryErr), true)
+	assertEqual(t, retryErr.Attempts, 2)
+	var statusErr *BadStatusCodeError
+	assertEqual(t, errors.As(err, &statusErr), true)
+	assertEqual(t, statusErr.StatusCode, 404)
+	assertEqual(t, atomic.LoadInt32(count), int32(2))
+}
+
+func TestFetcher_Fetch_RetryPermanentError(t *testing.T) {
+	var count int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(&count, 1)
+		http.Redirect(w, r, "/", http.StatusFound)
+	}))
+	defer server.Close()
+
+	fetcher := NewFetcher(FetcherOpts{Retry: &RetryPolicy{MaxAttempts: 3, BaseDelay: time.Millisecond}})
+	var ogp OGP
+	err := fetcher.Fetch(server.URL+"/", &ogp)
+	assertEqual(t, errors.Is(err, ErrRedirectLoop), true)
+	var retryErr *RetryError
+	assertEqual(t, errors.As(err, &retryErr), false)
+	assertEqual(t, atomic.LoadInt32(&count), int32(1))
+
+	fetcher = NewFetcher(FetcherOpts{
+		Retry:         &RetryPolicy{MaxAttempts: 3, BaseDelay: time.Millisecond},
+		NetworkPolicy: &NetworkPolicy{},
+	})
+	err = fetcher.Fetch("http://localhost/", &ogp)
+	var destErr *DestinationError
+	assertEqual(t, errors.As(err, &destErr), true)
+	assertEqual(t, errors.As(err, &retryErr), false)
+}
+
+func TestIsRetryable(t *testing.T) {
+	assertEqual(t, IsRetryable(&http.Response{StatusCode: 503}, nil), true)
+	assertEqual(t, IsRetryable(&http.Response{StatusCode: 404}, nil), false)
+
+	wrap := func(err error) error {
+		return &url.Error{Op: "Get", URL: "http://example.com", Err: err}
+	}
+	assertEqual(t, IsRetryable(nil, wrap(&net.OpError{Op: "dial", Err: os.NewSyscallError("connect", syscall.ECONNREFUSED)})), true)
+	assertEqual(t, IsRetryable(nil, wrap(&net.OpError{Op: "read", Err: os.NewSyscallError("read", syscall.ECONNRESET)})), true)
+	assertEqual(t, IsRetryable(nil, wrap(io.ErrUnexpectedEOF)), true)
+	assertEqual(t, IsRetryable(nil, wrap(&net.DNSError{Err: "timeout", IsTimeout: true})), true)
+
+	assertEqual(t, IsRetryable(nil, wrap(context.Canceled)), false)
+	assertEqual(t, IsRetryable(nil, wrap(ErrRedirectLoop)), false)
+	assertEqual(t, IsRetryable(nil, wrap(ErrTooManyRedirects)), false)
+	assertEqual(t, IsRetryable(nil, wrap(&DestinationError{})), false)
+	assertEqual(t, IsRetryable(nil, wrap(x509.UnknownAuthorityError{})), false)
+	assertEqual(t, IsRetryable(nil, wrap(errors.New("unsupported protocol scheme"))), false)
+}
+
+func TestFetcher_Fetch_RetryAfter(t *testing.T) {
+	var count int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(&count, 1)
+		w.Header().Set("Retry-After", "120")
+		w.WriteHeader(http.StatusServiceUnavailable)
+	}))
+	defer server.Close()
+
+	// It gives up, because Retry-After is longer than MaxDelay.
+	fetcher := NewFetcher(FetcherOpts{Retry: &RetryPolicy{MaxAttempts: 3, MaxDelay: time.Second}})
+	var ogp OGP
+	err := fetcher.Fetch(server.URL, &ogp)
+
+	var retryErr *RetryError
+	assertEqual(t, errors.As(err, &retryErr), true)
+	assertEqual(t, retryErr.Attempts, 1)
+	assertEqual(t, atomic.LoadInt32(&count), int32(1))
+}
+
+func TestFetcher_Fetch_RetryNetworkError(t *testing.T) {
+	server := httptest.NewServer(http.NotFoundHandler())
+	rawurl := server.URL
+	server.Close()
+
+	fetcher := NewFetcher(FetcherOpts{Retry: &RetryPolicy{MaxAttempts: 3, BaseDelay: time.Millisecond}})
+	var ogp OGP
+	var retryErr *RetryError
+	assertEqual(t, errors.As(fetcher.Fetch(rawurl, &ogp), &retryErr), true)
+	assertEqual(t, retryErr.Attempts, 3)
+}
+
+func TestRetryPolicy_Backoff(t *testing.T) {
+	p := &RetryPolicy{BaseDelay: 100 * time.Millisecond, MaxDelay: time.Second}
+	for i := 0; i < 100; i++ {
+		d := p.backoff(1)
+		if d < 50*time.Millisecond || d > 100*time.Millisecond {
+			t.Errorf("backoff(1) is out of range: %s", d)
+		}
+		d = p.backoff(3)
+		if d < 200*time.Millisecond || d > 400*time.Millisecond {
+			t.Errorf("backoff(3) is out of range: %s", d)
+		}
+		d = p.backoff(10)
+		if d < 500*time.Millisecond || d > time.Second {
+			t.Errorf("backoff(10) is out of range: %s", d)
+		}
+	}
+}
+
+func Test_RetryAfter(t *testing.T) {
+	now := time.Date(2020, 5, 20, 1, 1, 25, 0, time.UTC)
+	assertEqual(t, retryAfter(http.Header{}, now), time.Duration(0))
+	assertEqual(t, retryAfter(http.Header{"Retry-After": {"3"}}, now), 3*time.Second)
+	assertEqual(t, retryAfter(http.Header{"Retry-After": {"Wed, 20 May 2020 01:02:25 GMT"}}, now), time.Minute)
+	assertEqual(t, retryAfter(http.Header{"Retry-After": {"invalid"}}, now), time.Duration(0))
+}
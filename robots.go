@@ -0,0 +1,335 @@
+package googp
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"regexp"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	defaultRobotsUserAgent = "googp"
+	defaultRobotsTTL       = 24 * time.Hour
+	robotsMaxBytes         = 512 * 1024
+	// robotsServerErrorTTL is the duration to cache robots.txt which is unreachable because of server errors.
+	robotsServerErrorTTL = time.Minute
+)
+
+var (
+	// ErrDisallowedByRobots is an error returned when the URL is disallowed by robots.txt.
+	ErrDisallowedByRobots = errors.New("Disallowed by robots.txt")
+)
+
+// Robots is a checker of robots.txt.
+// It gets robots.txt of each host once and caches it.
+//
+// ref: https://www.rfc-editor.org/rfc/rfc9309
+type Robots struct {
+	opts  RobotsOpts
+	mu    sync.Mutex
+	hosts map[string]*robotsHost
+}
+
+// RobotsOpts is an option of Robots.
+type RobotsOpts struct {
+	// UserAgent is the name of the crawler, which is matched with `User-agent` lines. Default is "googp".
+	UserAgent string
+	// HTTP client used to get robots.txt. If it is nil, http.DefaultClient is used.
+	Client *http.Client
+	// Duration to cache robots.txt of each host. Default is 24h.
+	TTL time.Duration
+}
+
+// robotsHost is a cache of robots.txt of the host.
+type robotsHost struct {
+	ready   chan struct{}
+	rules   *robotsRules
+	err     error
+	expires time.Time
+}
+
+// robotsRules is the rules of robots.txt for a user agent.
+type robotsRules struct {
+	rules      []*robotsRule
+	crawlDelay time.Duration
+}
+
+type robotsRule struct {
+	allow   bool
+	pattern string
+	re      *regexp.Regexp
+}
+
+// NewRobots create a `Robots`
+func NewRobots(opts ...RobotsOpts) *Robots {
+	switch len(opts) {
+	case 0:
+		return &Robots{hosts: make(map[string]*robotsHost)}
+	case 1:
+		return &Robots{opts: opts[0], hosts: make(map[string]*robotsHost)}
+	default:
+		panic("Cannot specify multiple RobotsOpts")
+	}
+}
+
+// Check returns ErrDisallowedByRobots when the URL is disallowed by robots.txt.
+func (r *Robots) Check(ctx context.Context, rawurl string) error {
+	return r.check(ctx, r.opts.Client, rawurl)
+}
+
+// check is the same as Check, but it uses the client to get robots.txt.
+func (r *Robots) check(ctx context.Context, client *http.Client, rawurl string) error {
+	u, err := url.Parse(rawurl)
+	if err != nil {
+		return err
+	}
+	rules, err := r.get(ctx, client, u)
+	if err != nil {
+		return err
+	}
+	if !rules.isAllowed(u) {
+		return fmt.Errorf("%w (%s)", ErrDisallowedByRobots, rawurl)
+	}
+	return nil
+}
+
+// CrawlDelay returns the value of `Crawl-delay` for the host of the URL.
+// It returns 0 when it is not specified.
+func (r *Robots) CrawlDelay(ctx context.Context, rawurl string) (time.Duration, error) {
+	return r.crawlDelay(ctx, r.opts.Client, rawurl)
+}
+
+// crawlDelay is the same as CrawlDelay, but it uses the client to get robots.txt.
+func (r *Robots) crawlDelay(ctx context.Context, client *http.Client, rawurl string) (time.Duration, error) {
+	u, err := url.Parse(rawurl)
+	if err != nil {
+		return 0, err
+	}
+	rules, err := r.get(ctx, client, u)
+	if err != nil {
+		return 0, err
+	}
+	return rules.crawlDelay, nil
+}
+
+func (r *Robots) get(ctx context.Context, client *http.Client, u *url.URL) (*robotsRules, error) {
+	if u.Scheme != "http" && u.Scheme != "https" {
+		return &robotsRules{}, nil
+	}
+	key := strings.ToLower(u.Scheme + "://" + u.Host)
+
+	r.mu.Lock()
+	host := r.hosts[key]
+	if host != nil {
+		select {
+		case <-host.ready:
+			if host.err != nil || time.Now().After(host.expires) {
+				host = nil
+			}
+		default:
+		}
+	}
+	if host == nil {
+		host = &robotsHost{ready: make(chan struct{})}
+		r.hosts[key] = host
+		r.mu.Unlock()
+
+		var ttl time.Duration
+		host.rules, ttl, host.err = r.fetch(ctx, client, key+"/robots.txt")
+		host.expires = time.Now().Add(ttl)
+		close(host.ready)
+	} else {
+		r.mu.Unlock()
+	}
+
+	select {
+	case <-host.ready:
+		return host.rules, host.err
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	}
+}
+
+// fetch returns the rules of robots.txt and the duration to cache them.
+func (r *Robots) fetch(ctx context.Context, client *http.Client, rawurl string) (*robotsRules, time.Duration, error) {
+	req, err := http.NewRequestWithContext(ctx, "GET", rawurl, nil)
+	if err != nil {
+		return nil, 0, err
+	}
+	req.Header.Set("User-Agent", r.userAgent())
+
+	if client == nil {
+		client = http.DefaultClient
+	}
+	res, err := client.Do(req)
+	if err != nil {
+		return nil, 0, fmt.Errorf("Failed to get robots.txt: %w", err)
+	}
+	defer res.Body.Close()
+
+	switch {
+	case res.StatusCode >= 200 && res.StatusCode < 300:
+		return parseRobots(io.LimitReader(res.Body, robotsMaxBytes), r.userAgent()), r.ttl(), nil
+	case res.StatusCode >= 400 && res.StatusCode < 500:
+		// NOTE: If robots.txt is unavailable, crawlers may access any resources.
+		return &robotsRules{}, r.ttl(), nil
+	default:
+		// NOTE: If robots.txt is unreachable because of server errors, crawlers must assume complete disallow.
+		//       It is cached briefly, since the server errors are usually temporary.
+		ttl := r.ttl()
+		if ttl > robotsServerErrorTTL {
+			ttl = robotsServerErrorTTL
+		}
+		return &robotsRules{rules: []*robotsRule{newRobotsRule(false, "/")}}, ttl, nil
+	}
+}
+
+func (r *Robots) userAgent() string {
+	if r.opts.UserAgent != "" {
+		return r.opts.UserAgent
+	}
+	return defaultRobotsUserAgent
+}
+
+func (r *Robots) ttl() time.Duration {
+	if r.opts.TTL > 0 {
+		return r.opts.TTL
+	}
+	return defaultRobotsTTL
+}
+
+// parseRobots returns the rules for the user agent.
+// The most specific group that matches the user agent is used, and `*` is used when there is no such group.
+func parseRobots(reader io.Reader, userAgent string) *robotsRules {
+	type group struct {
+		agents []string
+		rules  robotsRules
+	}
+
+	var (
+		groups  []*group
+		current *group
+		inRules bool
+	)
+	scanner := bufio.NewScanner(reader)
+	for scanner.Scan() {
+		line := scanner.Text()
+		if i := strings.Index(line, "#"); i >= 0 {
+			line = line[:i]
+		}
+		i := strings.Index(line, ":")
+		if i < 0 {
+			continue
+		}
+		key := strings.ToLower(strings.TrimSpace(line[:i]))
+		value := strings.TrimSpace(line[i+1:])
+
+		switch key {
+		case "user-agent":
+			if current == nil || inRules {
+				current = &group{}
+				groups = append(groups, current)
+				inRules = false
+			}
+			current.agents = append(current.agents, strings.ToLower(value))
+		case "allow", "disallow":
+			if current == nil {
+				continue
+			}
+			inRules = true
+			if value != "" {
+				current.rules.rules = append(current.rules.rules, newRobotsRule(key == "allow", value))
+			}
+		case "crawl-delay":
+			if current == nil {
+				continue
+			}
+			inRules = true
+			if sec, err := strconv.ParseFloat(value, 64); err == nil && sec >= 0 {
+				current.rules.crawlDelay = time.Duration(sec * float64(time.Second))
+			}
+		}
+	}
+
+	// NOTE: Groups with the same user agent are merged.
+	var (
+		matched  *robotsRules
+		matchLen = -1
+	)
+	ua := strings.ToLower(userAgent)
+	for _, g := range groups {
+		l := -1
+		for _, agent := range g.agents {
+			if agent == "*" && l < 0 {
+				l = 0
+			} else if agent != "" && strings.Contains(ua, agent) && len(agent) > l {
+				l = len(agent)
+			}
+		}
+		if l < 0 || l < matchLen {
+			continue
+		}
+
+		if l > matchLen {
+			matched = &robotsRules{}
+			matchLen = l
+		}
+		matched.rules = append(matched.rules, g.rules.rules...)
+		if g.rules.crawlDelay > matched.crawlDelay {
+			matched.crawlDelay = g.rules.crawlDelay
+		}
+	}
+
+	if matched == nil {
+		return &robotsRules{}
+	}
+	return matched
+}
+
+func newRobotsRule(allow bool, pattern string) *robotsRule {
+	parts := strings.Split(strings.TrimSuffix(pattern, "$"), "*")
+	for i, part := range parts {
+		parts[i] = regexp.QuoteMeta(part)
+	}
+	expr := "^" + strings.Join(parts, ".*")
+	if strings.HasSuffix(pattern, "$") {
+		expr += "$"
+	}
+	return &robotsRule{allow: allow, pattern: pattern, re: regexp.MustCompile(expr)}
+}
+
+// isAllowed returns true when the URL is allowed.
+// The longest matching rule is used, and `Allow` is used when the rules are the same length.
+func (r *robotsRules) isAllowed(u *url.URL) bool {
+	path := u.EscapedPath()
+	if path == "" {
+		path = "/"
+	}
+	if u.RawQuery != "" {
+		path += "?" + u.RawQuery
+	}
+	if path == "/robots.txt" {
+		return true
+	}
+
+	var matched *robotsRule
+	for _, rule := range r.rules {
+		if !rule.re.MatchString(path) {
+			continue
+		}
+		if matched == nil ||
+			len(rule.pattern) > len(matched.pattern) ||
+			(len(rule.pattern) == len(matched.pattern) && rule.allow) {
+			matched = rule
+		}
+	}
+	return matched == nil || matched.allow
+}
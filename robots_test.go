@@ -0,0 +1,176 @@
+package googp
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestRobots(t *testing.T) {
+	server := httptest.NewServer(http.FileServer(http.Dir("data")))
+	defer server.Close()
+
+	ctx := context.Background()
+	robots := NewRobots()
+	assertNoError(t, robots.Check(ctx, server.URL+"/1.html"))
+	assertNoError(t, robots.Check(ctx, server.URL+"/2.html"))
+	assertNoError(t, robots.Check(ctx, server.URL+"/private/public.html"))
+	assertNoError(t, robots.Check(ctx, server.URL+"/index.php?a=b"))
+	assertEqual(t, errors.Is(robots.Check(ctx, server.URL+"/private/secret.html"), ErrDisallowedByRobots), true)
+	assertEqual(t, errors.Is(robots.Check(ctx, server.URL+"/index.php"), ErrDisallowedByRobots), true)
+
+	d, err := robots.CrawlDelay(ctx, server.URL)
+	assertNoError(t, err)
+	assertEqual(t, d, 2*time.Second)
+
+	robots = NewRobots(RobotsOpts{UserAgent: "Mozilla/5.0 (compatible; googp-test/1.0)"})
+	assertNoError(t, robots.Check(ctx, server.URL+"/1.html"))
+	assertNoError(t, robots.Check(ctx, server.URL+"/private/secret.html"))
+	assertNoError(t, robots.Check(ctx, server.URL+"/3.html"))
+	assertEqual(t, errors.Is(robots.Check(ctx, server.URL+"/2.html"), ErrDisallowedByRobots), true)
+	assertEqual(t, errors.Is(robots.Check(ctx, server.URL+"/3.html?a=b"), ErrDisallowedByRobots), true)
+
+	d, err = robots.CrawlDelay(ctx, server.URL)
+	assertNoError(t, err)
+	assertEqual(t, d, 50*time.Millisecond)
+}
+
+func TestRobots_Cache(t *testing.T) {
+	var count int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(&count, 1)
+		assertEqual(t, r.URL.Path, "/robots.txt")
+		assertEqual(t, r.Header.Get("User-Agent"), "googp")
+		w.Write([]byte("User-agent: *\nDisallow: /private\n"))
+	}))
+	defer server.Close()
+
+	ctx := context.Background()
+	robots := NewRobots()
+	for i := 0; i < 3; i++ {
+		assertNoError(t, robots.Check(ctx, server.URL+"/index.html"))
+	}
+	assertEqual(t, atomic.LoadInt32(&count), int32(1))
+
+	robots = NewRobots(RobotsOpts{TTL: time.Nanosecond})
+	for i := 0; i < 3; i++ {
+		assertNoError(t, robots.Check(ctx, server.URL+"/index.html"))
+		time.Sleep(time.Millisecond)
+	}
+	assertEqual(t, atomic.LoadInt32(&count), int32(4))
+}
+
+func TestRobots_Status(t *testing.T) {
+	var status int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(int(atomic.LoadInt32(&status)))
+	}))
+	defer server.Close()
+
+	ctx := context.Background()
+	atomic.StoreInt32(&status, 404)
+	assertNoError(t, NewRobots().Check(ctx, server.URL+"/index.html"))
+
+	atomic.StoreInt32(&status, 503)
+	robots := NewRobots()
+	assertEqual(t, errors.Is(robots.Check(ctx, server.URL+"/index.html"), ErrDisallowedByRobots), true)
+	// The server errors are cached briefly.
+	host := robots.hosts[strings.ToLower(server.URL)]
+	assertEqual(t, host.expires.Before(time.Now().Add(robotsServerErrorTTL)), true)
+
+	atomic.StoreInt32(&status, 404)
+	host.expires = time.Now()
+	assertNoError(t, robots.Check(ctx, server.URL+"/index.html"))
+}
+
+func TestFetcher_Fetch_Robots(t *testing.T) {
+	files := http.FileServer(http.Dir("data"))
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assertEqual(t, r.Header.Get("User-Agent"), "googp-test")
+		files.ServeHTTP(w, r)
+	}))
+	defer server.Close()
+
+	fetcher := NewFetcher(FetcherOpts{Robots: NewRobots(RobotsOpts{UserAgent: "googp-test"})})
+	var ogp OGP
+	assertNoError(t, fetcher.Fetch(server.URL+"/1.html", &ogp))
+	assertEqual(t, ogp.Title, "title")
+	assertEqual(t, errors.Is(fetcher.Fetch(server.URL+"/2.html", &ogp), ErrDisallowedByRobots), true)
+
+	var count int
+	for result := range fetcher.FetchAll(context.Background(), []string{server.URL + "/1.html", server.URL + "/2.html"}) {
+		if errors.Is(result.Err, ErrDisallowedByRobots) {
+			count++
+		}
+	}
+	assertEqual(t, count, 1)
+}
+
+func TestFetcher_Fetch_RobotsRedirect(t *testing.T) {
+	var fetched bool
+	mux := http.NewServeMux()
+	mux.HandleFunc("/robots.txt", func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte("User-agent: *\nDisallow: /private/\n"))
+	})
+	mux.Handle("/", http.RedirectHandler("/private/1.html", http.StatusFound))
+	mux.HandleFunc("/private/", func(w http.ResponseWriter, r *http.Request) {
+		fetched = true
+		http.ServeFile(w, r, "data/1.html")
+	})
+	server := httptest.NewServer(mux)
+	defer server.Close()
+
+	fetcher := NewFetcher(FetcherOpts{Robots: NewRobots()})
+	var ogp OGP
+	assertEqual(t, errors.Is(fetcher.Fetch(server.URL+"/", &ogp), ErrDisallowedByRobots), true)
+	assertEqual(t, fetched, false)
+}
+
+func Test_ParseRobots(t *testing.T) {
+	txt := `
+User-agent: a
+Disallow: /a
+User-agent: b
+Disallow: /b
+
+user-agent: a # merged with the first group
+DISALLOW: /c
+Allow: /c/d$
+Disallow:
+Disallow: /*.gif$
+Disallow: /e*f
+`
+	isAllowed := func(rules *robotsRules, path string) bool {
+		u, err := url.Parse("http://example.com" + path)
+		assertNoError(t, err)
+		return rules.isAllowed(u)
+	}
+
+	rules := parseRobots(strings.NewReader(txt), "a")
+	assertEqual(t, isAllowed(rules, "/"), true)
+	assertEqual(t, isAllowed(rules, "/a"), false)
+	assertEqual(t, isAllowed(rules, "/abc"), false)
+	assertEqual(t, isAllowed(rules, "/b"), true)
+	assertEqual(t, isAllowed(rules, "/c"), false)
+	assertEqual(t, isAllowed(rules, "/c/d"), true)
+	assertEqual(t, isAllowed(rules, "/c/d/e"), false)
+	assertEqual(t, isAllowed(rules, "/x/y.gif"), false)
+	assertEqual(t, isAllowed(rules, "/x/y.gif?z"), true)
+	assertEqual(t, isAllowed(rules, "/e/x/f"), false)
+	assertEqual(t, isAllowed(rules, "/robots.txt"), true)
+
+	rules = parseRobots(strings.NewReader(txt), "b")
+	assertEqual(t, isAllowed(rules, "/a"), true)
+	assertEqual(t, isAllowed(rules, "/b"), false)
+	assertEqual(t, isAllowed(rules, "/c"), true)
+
+	rules = parseRobots(strings.NewReader(txt), "c")
+	assertEqual(t, isAllowed(rules, "/a"), true)
+	assertEqual(t, len(rules.rules), 0)
+}
@@ -18,27 +18,30 @@ type OGP struct {
 }
 
 // Image is a model that structure contents of og:image.
+// The relative names are used when it is mounted under another property. (e.g. `twitter:image`)
 type Image struct {
-	URL       string `googp:"og:image,og:image:url" json:"url,omitempty"`
-	SecureURL string `googp:"og:image:secure_url"   json:"secure_url,omitempty"`
-	Type      string `googp:"og:image:type"         json:"type,omitempty"`
-	Width     int    `googp:"og:image:width"        json:"width,omitempty"`
-	Height    int    `googp:"og:image:height"       json:"height,omitempty"`
-	Alt       string `googp:"og:image:alt"          json:"alt,omitempty"`
+	URL       string `googp:"og:image,og:image:url,:,:url"    json:"url,omitempty"`
+	SecureURL string `googp:"og:image:secure_url,:secure_url" json:"secure_url,omitempty"`
+	Type      string `googp:"og:image:type,:type"             json:"type,omitempty"`
+	Width     int    `googp:"og:image:width,:width"           json:"width,omitempty"`
+	Height    int    `googp:"og:image:height,:height"         json:"height,omitempty"`
+	Alt       string `googp:"og:image:alt,:alt"               json:"alt,omitempty"`
 }
 
 // Audio is a model that structure contents of og:audio.
+// The relative names are used when it is mounted under another property.
 type Audio struct {
-	URL       string `googp:"og:audio,og:audio:url" json:"url,omitempty"`
-	SecureURL string `googp:"og:audio:secure_url"   json:"secure_url,omitempty"`
-	Type      string `googp:"og:audio:type"         json:"type,omitempty"`
+	URL       string `googp:"og:audio,og:audio:url,:,:url"    json:"url,omitempty"`
+	SecureURL string `googp:"og:audio:secure_url,:secure_url" json:"secure_url,omitempty"`
+	Type      string `googp:"og:audio:type,:type"             json:"type,omitempty"`
 }
 
 // Video is a model that structure contents of og:video.
+// The relative names are used when it is mounted under another property.
 type Video struct {
-	URL       string `googp:"og:video,og:video:url" json:"url,omitempty"`
-	SecureURL string `googp:"og:video:secure_url"   json:"secure_url,omitempty"`
-	Type      string `googp:"og:video:type"         json:"type,omitempty"`
-	Width     int    `googp:"og:video:width"        json:"width,omitempty"`
-	Height    int    `googp:"og:video:height"       json:"height,omitempty"`
+	URL       string `googp:"og:video,og:video:url,:,:url"    json:"url,omitempty"`
+	SecureURL string `googp:"og:video:secure_url,:secure_url" json:"secure_url,omitempty"`
+	Type      string `googp:"og:video:type,:type"             json:"type,omitempty"`
+	Width     int    `googp:"og:video:width,:width"           json:"width,omitempty"`
+	Height    int    `googp:"og:video:height,:height"         json:"height,omitempty"`
 }
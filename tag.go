@@ -2,38 +2,115 @@ package googp
 
 import (
 	"reflect"
+	"strconv"
 	"strings"
 )
 
 const (
-	structTagKey = "googp"
+	structTagKey     = "googp"
+	defaultNamespace = "og"
+)
+
+// tagRules is rules to create a `*tag` from a struct field.
+type tagRules struct {
+	// Key of the struct tag.
+	key string
+	// Namespace of the names inferred from the field names.
+	namespace string
+	// If it is true, the fields without the tag are ignored.
+	disableInference bool
+}
+
+var (
+	defaultTagRules = tagRules{key: structTagKey, namespace: defaultNamespace}
 )
 
 // tag is struct tag supported by googp
 type tag struct {
 	// Array of OGP property names. (e.g. `og:title`)
 	names []string
+	// If it is true, the property must be present.
+	required bool
+	// Value used when the property is absent.
+	defaultValue *string
+	// Policy used when the property appears more than once. If it is nil, ParserOpts.ConflictPolicy is used.
+	conflict *ConflictPolicy
+	// If it is true, the field collects the conflicting values of the properties instead of the values.
+	alternates bool
+	// Maximum number of elements of the slice. If it is 0, ParserOpts.MaxElements is used.
+	max int
+	// If it is true, the name is inferred from the field name.
+	inferred bool
 }
 
 // newTag is create a `*tag` from `reflect.StructField`
+//
+// The value of the tag is comma-separated names and options.
+// The names which start with `:` are relative to the parent field. (e.g. `:secure_url` under `og:image`)
+// Options are `required`, `conflict=${policy}`, `alternates`, `max=${n}` and `default=${value}`,
+// and `default` must be the last since the value may have commas.
 func newTag(f reflect.StructField) *tag {
-	value := f.Tag.Get(structTagKey)
+	return defaultTagRules.newTag(f)
+}
+
+// newTag is create a `*tag` from `reflect.StructField` according to the rules.
+func (rules tagRules) newTag(f reflect.StructField) *tag {
+	value := f.Tag.Get(rules.key)
+	if value == "-" {
+		return &tag{names: []string{}}
+	}
+
+	t := &tag{}
 	var names []string
+	for value != "" {
+		var item string
+		if strings.HasPrefix(value, "default=") {
+			item, value = value, ""
+		} else if i := strings.Index(value, ","); i >= 0 {
+			item, value = value[:i], value[i+1:]
+		} else {
+			item, value = value, ""
+		}
+
+		switch {
+		case item == "required":
+			t.required = true
+		case item == "alternates":
+			t.alternates = true
+		case strings.HasPrefix(item, "max="):
+			if n, err := strconv.Atoi(strings.TrimPrefix(item, "max=")); err == nil && n > 0 {
+				t.max = n
+			}
+		case strings.HasPrefix(item, "conflict="):
+			if policy, ok := parseConflictPolicy(strings.TrimPrefix(item, "conflict=")); ok {
+				t.conflict = &policy
+			}
+		case strings.HasPrefix(item, "default="):
+			defaultValue := strings.TrimPrefix(item, "default=")
+			t.defaultValue = &defaultValue
+		default:
+			names = append(names, item)
+		}
+	}
 
-	switch value {
-	case "-":
-		names = []string{}
-	case "":
+	if len(names) == 0 {
 		if f.Anonymous {
 			names = []string{""}
-		} else {
+		} else if !rules.disableInference {
 			// NOTE: If tag is not specified, it is same as being given `og:${field_name}`.
-			names = []string{"og:" + toSnake(f.Name)}
+			names = []string{rules.namespace + ":" + toSnake(f.Name)}
+			t.inferred = true
+		} else {
+			names = []string{}
 		}
-	default:
-		names = strings.Split(value, ",")
 	}
-	return &tag{names: names}
+	t.names = names
+	return t
+}
+
+// isInline returns true, when the tag of the embedded field does not have the names.
+func (t *tag) isInline() bool {
+	return len(t.names) == 1 && t.names[0] == ""
 }
 
 // isContainsName returns true, when the tag contains the name.
@@ -47,6 +124,35 @@ func (t *tag) isContainsName(name string) bool {
 	return false
 }
 
+// hasRelativeName returns true, when the tag contains a name relative to the parent field. (e.g. `:secure_url`)
+func (t *tag) hasRelativeName() bool {
+	for _, n := range t.names {
+		if isRelativeName(n) {
+			return true
+		}
+	}
+	return false
+}
+
+// resolve returns the tag which the relative names are resolved with the root.
+// The duplicated names are removed. (e.g. `og:image` and `:` for `og:image`)
+func (t *tag) resolve(root string) *tag {
+	resolved := *t
+	resolved.names = make([]string, 0, len(t.names))
+	for _, name := range t.names {
+		if name = resolveName(root, name); !resolved.isContainsName(name) {
+			resolved.names = append(resolved.names, name)
+		}
+	}
+	return &resolved
+}
+
+// isRelativeName returns true, when the name is relative to the parent field.
+// `:${name}` is `${parent}:${name}`, and `:` is the parent itself.
+func isRelativeName(name string) bool {
+	return strings.HasPrefix(name, ":")
+}
+
 // toSnake converts the string into a snake case.
 func toSnake(str string) string {
 	runes := []rune(str)
@@ -19,8 +19,11 @@ func Test_Tag(t *testing.T) {
 	assertEqual(t, tag.isContainsName("og:description"), true)
 	assertEqual(t, tag.isContainsName("og"), false)
 
+	assertEqual(t, tag.inferred, false)
+
 	tag = newTag(reflect.TypeOf(v).Field(1))
 	assertEqual(t, tag.names, []string{"og:b"})
+	assertEqual(t, tag.inferred, true)
 
 	tag = newTag(reflect.TypeOf(v).Field(2))
 	assertEqual(t, tag.names, []string{})
@@ -29,6 +32,67 @@ func Test_Tag(t *testing.T) {
 	assertEqual(t, tag.names, []string{""})
 }
 
+func Test_Tag_Options(t *testing.T) {
+	var v struct {
+		A string `googp:"og:title,required"`
+		B string `googp:"og:type,default=website"`
+		C string `googp:"og:description,og:site_name,required,default=a,b"`
+		D string `googp:"required"`
+		E string `googp:"og:title,conflict=last"`
+		F string `googp:"og:title,alternates,conflict=unknown"`
+		G string `googp:"og:image,max=10"`
+	}
+
+	tag := newTag(reflect.TypeOf(v).Field(0))
+	assertEqual(t, tag.names, []string{"og:title"})
+	assertEqual(t, tag.required, true)
+	assertEqual(t, tag.defaultValue == nil, true)
+
+	tag = newTag(reflect.TypeOf(v).Field(1))
+	assertEqual(t, tag.names, []string{"og:type"})
+	assertEqual(t, tag.required, false)
+	assertEqual(t, *tag.defaultValue, "website")
+
+	tag = newTag(reflect.TypeOf(v).Field(2))
+	assertEqual(t, tag.names, []string{"og:description", "og:site_name"})
+	assertEqual(t, tag.required, true)
+	assertEqual(t, *tag.defaultValue, "a,b")
+
+	tag = newTag(reflect.TypeOf(v).Field(3))
+	assertEqual(t, tag.names, []string{"og:d"})
+	assertEqual(t, tag.required, true)
+
+	tag = newTag(reflect.TypeOf(v).Field(4))
+	assertEqual(t, *tag.conflict, ConflictLast)
+	assertEqual(t, tag.alternates, false)
+
+	tag = newTag(reflect.TypeOf(v).Field(5))
+	assertEqual(t, tag.conflict == nil, true)
+	assertEqual(t, tag.alternates, true)
+
+	tag = newTag(reflect.TypeOf(v).Field(6))
+	assertEqual(t, tag.names, []string{"og:image"})
+	assertEqual(t, tag.max, 10)
+}
+
+func Test_TagRules(t *testing.T) {
+	var v struct {
+		A string `googp:"og:title" og:"og:description"`
+		B string
+		OGP
+	}
+
+	rules := tagRules{key: "og", namespace: "twitter"}
+	assertEqual(t, rules.newTag(reflect.TypeOf(v).Field(0)).names, []string{"og:description"})
+	assertEqual(t, rules.newTag(reflect.TypeOf(v).Field(1)).names, []string{"twitter:b"})
+	assertEqual(t, rules.newTag(reflect.TypeOf(v).Field(2)).names, []string{""})
+
+	rules = tagRules{key: "googp", namespace: "og", disableInference: true}
+	assertEqual(t, rules.newTag(reflect.TypeOf(v).Field(0)).names, []string{"og:title"})
+	assertEqual(t, rules.newTag(reflect.TypeOf(v).Field(1)).names, []string{})
+	assertEqual(t, rules.newTag(reflect.TypeOf(v).Field(2)).names, []string{""})
+}
+
 func TestToSnake(t *testing.T) {
 	assertEqual(t, toSnake("CreatedAt"), "created_at")
 	assertEqual(t, toSnake("ID"), "id")
@@ -0,0 +1,39 @@
+package googp
+
+import (
+	"reflect"
+)
+
+// Unmarshaler is the interface implemented by types that can unmarshal the properties by themselves.
+//
+// UnmarshalOGP is called for each property under the root of the field. (e.g. `product:price:amount` and
+// `product:price:currency` of the field tagged `product:price`)
+// It takes precedence over encoding.TextUnmarshaler and the fields of the struct.
+type Unmarshaler interface {
+	UnmarshalOGP(key string, value string) error
+}
+
+// UnmarshalFinisher is the interface implemented by Unmarshaler that needs to be notified after all properties are unmarshaled.
+type UnmarshalFinisher interface {
+	FinishOGP() error
+}
+
+// unmarshalerAccessor is an accessor for writing the values of ogp to an Unmarshaler.
+type unmarshalerAccessor struct {
+	unmarshaler Unmarshaler
+}
+
+var (
+	unmarshalerType = reflect.TypeOf((*Unmarshaler)(nil)).Elem()
+)
+
+func (ac *unmarshalerAccessor) Set(key string, val string) error {
+	return ac.unmarshaler.UnmarshalOGP(key, val)
+}
+
+func (ac *unmarshalerAccessor) Finish() error {
+	if finisher, ok := ac.unmarshaler.(UnmarshalFinisher); ok {
+		return finisher.FinishOGP()
+	}
+	return nil
+}
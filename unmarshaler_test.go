@@ -0,0 +1,87 @@
+package googp
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"testing"
+)
+
+type Money struct {
+	Amount   float64
+	Currency string
+}
+
+func (m *Money) UnmarshalOGP(key string, value string) error {
+	switch {
+	case strings.HasSuffix(key, ":amount"):
+		amount, err := strconv.ParseFloat(value, 64)
+		if err != nil {
+			return err
+		}
+		m.Amount = amount
+	case strings.HasSuffix(key, ":currency"):
+		m.Currency = value
+	}
+	return nil
+}
+
+func (m *Money) FinishOGP() error {
+	if m.Currency == "" {
+		return errors.New("currency is missing")
+	}
+	return nil
+}
+
+type GeoPoint struct {
+	Latitude  string
+	Longitude string
+}
+
+func (p *GeoPoint) UnmarshalOGP(key string, value string) error {
+	switch key {
+	case "place:location:latitude":
+		p.Latitude = value
+	case "place:location:longitude":
+		p.Longitude = value
+	}
+	return nil
+}
+
+func (p *GeoPoint) String() string {
+	return fmt.Sprintf("(%s, %s)", p.Latitude, p.Longitude)
+}
+
+func TestParser_Parse_Unmarshaler(t *testing.T) {
+	type Product struct {
+		Title    string    `googp:"og:title"`
+		Price    Money     `googp:"product:price"`
+		Location *GeoPoint `googp:"place:location"`
+	}
+
+	var v Product
+	html := `<html><head>
+		<meta property="og:title" content="title" />
+		<meta property="product:price:amount" content="12.5" />
+		<meta property="product:price:currency" content="USD" />
+		<meta property="place:location:latitude" content="35.681236" />
+		<meta property="place:location:longitude" content="139.767125" />
+	</head></html>`
+	assertNoError(t, NewParser().Parse(strings.NewReader(html), &v))
+	assertEqual(t, v.Title, "title")
+	assertEqual(t, v.Price, Money{Amount: 12.5, Currency: "USD"})
+	assertEqual(t, v.Location.String(), "(35.681236, 139.767125)")
+
+	html = `<html><head>
+		<meta property="product:price:amount" content="12.5" />
+	</head></html>`
+	v = Product{}
+	assertError(t, NewParser().Parse(strings.NewReader(html), &v))
+
+	html = `<html><head>
+		<meta property="product:price:amount" content="invalid" />
+	</head></html>`
+	v = Product{}
+	assertError(t, NewParser().Parse(strings.NewReader(html), &v))
+}
@@ -0,0 +1,131 @@
+package warc
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/url"
+
+	"github.com/soranoba/googp"
+)
+
+var (
+	// urlProperties is the properties which have a URL. They are resolved with the target URI.
+	urlProperties = map[string]bool{
+		"og:url":              true,
+		"og:image":            true,
+		"og:image:url":        true,
+		"og:image:secure_url": true,
+		"og:video":            true,
+		"og:video:url":        true,
+		"og:video:secure_url": true,
+		"og:audio":            true,
+		"og:audio:url":        true,
+		"og:audio:secure_url": true,
+	}
+)
+
+// Result is a result of each response record in Extract.
+type Result struct {
+	// TargetURI is the value of WARC-Target-URI, which is the URL of the page.
+	TargetURI string
+	// RecordID is the value of WARC-Record-ID.
+	RecordID string
+	// Value is the value returned by newValue of Extract, which OGP information is parsed into.
+	Value interface{}
+	// Err is an error occurred while parsing the record.
+	Err error
+}
+
+// Extract reads the WARC records and parses OGP information of each HTTP response record with googp.Parse.
+// The results are sent in order of the records, and other types of records are skipped.
+//
+// The relative URLs of `og:url`, `og:image`, `og:video`, `og:audio` and their `url` and `secure_url` properties
+// are resolved with the target URI of the record.
+//
+// newValue returns the value which OGP information of the page is parsed into. If it is nil, `*googp.OGP` is used.
+//
+// The returned channel is closed when all records are processed, it fails to read the WARC, or the context is done,
+// so the caller must receive from it until it is closed.
+// When it fails to read the WARC, the last result has the error and empty TargetURI.
+func Extract(ctx context.Context, r io.Reader, newValue func(targetURI string) interface{}, opts ...googp.ParserOpts) <-chan *Result {
+	if newValue == nil {
+		newValue = func(string) interface{} { return &googp.OGP{} }
+	}
+
+	results := make(chan *Result)
+	go func() {
+		defer close(results)
+
+		send := func(result *Result) bool {
+			select {
+			case results <- result:
+				return true
+			case <-ctx.Done():
+				return false
+			}
+		}
+
+		reader, err := NewReader(r)
+		if err != nil {
+			send(&Result{Err: err})
+			return
+		}
+
+		for ctx.Err() == nil {
+			rec, err := reader.Next()
+			if err == io.EOF {
+				return
+			}
+			if err != nil {
+				send(&Result{Err: err})
+				return
+			}
+			if !rec.IsHTTPResponse() {
+				continue
+			}
+
+			result := &Result{
+				TargetURI: rec.TargetURI(),
+				RecordID:  rec.RecordID(),
+				Value:     newValue(rec.TargetURI()),
+			}
+			if res, err := rec.HTTPResponse(); err != nil {
+				result.Err = err
+			} else {
+				result.Err = parse(res, rec.TargetURI(), result.Value, opts...)
+			}
+
+			if !send(result) {
+				return
+			}
+		}
+	}()
+	return results
+}
+
+// parse parses OGP information of the response, with resolving the relative URLs with the target URI.
+func parse(res *http.Response, targetURI string, i interface{}, opts ...googp.ParserOpts) error {
+	var doc googp.Document
+	if err := googp.Parse(res, &doc, opts...); err != nil {
+		return err
+	}
+
+	base, err := url.Parse(targetURI)
+	if err != nil {
+		return doc.Decode(i, opts...)
+	}
+
+	metas := make([]*googp.Meta, len(doc.Metas()))
+	for idx, meta := range doc.Metas() {
+		metas[idx] = meta
+		if !urlProperties[meta.Property] || meta.Content == "" {
+			continue
+		}
+		// NOTE: The absolute URLs are kept as they are, and the invalid URLs are ignored.
+		if ref, err := url.Parse(meta.Content); err == nil && !ref.IsAbs() {
+			metas[idx] = &googp.Meta{Property: meta.Property, Content: base.ResolveReference(ref).String()}
+		}
+	}
+	return googp.NewDocument(metas).Decode(i, opts...)
+}
@@ -0,0 +1,126 @@
+package warc
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+	"testing"
+
+	"github.com/soranoba/googp"
+)
+
+func TestExtract(t *testing.T) {
+	for _, name := range []string{"testdata/example.warc", "testdata/example.warc.gz"} {
+		f, err := os.Open(name)
+		assertNoError(t, err)
+		defer f.Close()
+
+		var results []*Result
+		for result := range Extract(context.Background(), f, nil) {
+			results = append(results, result)
+		}
+		assertEqual(t, len(results), 3)
+
+		assertEqual(t, results[0].TargetURI, "http://example.com/1.html")
+		assertNoError(t, results[0].Err)
+		assertEqual(t, results[0].Value.(*googp.OGP).Title, "title")
+
+		assertEqual(t, results[1].TargetURI, "http://example.com/6.html")
+		assertNoError(t, results[1].Err)
+		assertEqual(t, results[1].Value.(*googp.OGP).Title, "ShiftJISタイトル")
+
+		assertEqual(t, results[2].TargetURI, "http://example.com/notfound.html")
+		var statusErr *googp.BadStatusCodeError
+		assertEqual(t, errors.As(results[2].Err, &statusErr), true)
+		assertEqual(t, statusErr.StatusCode, 404)
+	}
+}
+
+func TestExtract_NewValue(t *testing.T) {
+	f, err := os.Open("testdata/example.warc.gz")
+	assertNoError(t, err)
+	defer f.Close()
+
+	type Title struct {
+		Title string `googp:"og:title"`
+		URL   string `googp:"-"`
+	}
+
+	newValue := func(targetURI string) interface{} {
+		return &Title{URL: targetURI}
+	}
+	opts := googp.ParserOpts{AcceptStatus: googp.AcceptStatusCodes(200, 404)}
+
+	var titles []*Title
+	for result := range Extract(context.Background(), f, newValue, opts) {
+		assertNoError(t, result.Err)
+		titles = append(titles, result.Value.(*Title))
+	}
+	assertEqual(t, titles, []*Title{
+		{Title: "title", URL: "http://example.com/1.html"},
+		{Title: "ShiftJISタイトル", URL: "http://example.com/6.html"},
+		{Title: "", URL: "http://example.com/notfound.html"},
+	})
+}
+
+func TestExtract_ResolveURLs(t *testing.T) {
+	body := `<html><head>
+<meta property="og:url" content="/article" />
+<meta property="og:image" content="images/1.png" />
+<meta property="og:image:secure_url" content="//cdn.example.com/1.png" />
+<meta property="og:image" content="http://example.org/2.png" />
+<meta property="og:description" content="/not/url" />
+</head></html>`
+	payload := fmt.Sprintf("HTTP/1.1 200 OK\r\nContent-Type: text/html\r\nContent-Length: %d\r\n\r\n%s", len(body), body)
+	record := fmt.Sprintf("WARC/1.0\r\n"+
+		"WARC-Type: response\r\n"+
+		"WARC-Record-ID: <urn:uuid:00000000-0000-0000-0000-000000000000>\r\n"+
+		"WARC-Target-URI: https://example.com/blog/post.html\r\n"+
+		"Content-Type: application/http; msgtype=response\r\n"+
+		"Content-Length: %d\r\n\r\n%s\r\n\r\n", len(payload), payload)
+
+	var results []*Result
+	for result := range Extract(context.Background(), strings.NewReader(record), nil) {
+		results = append(results, result)
+	}
+	assertEqual(t, len(results), 1)
+	assertNoError(t, results[0].Err)
+
+	ogp := results[0].Value.(*googp.OGP)
+	assertEqual(t, ogp.URL, "https://example.com/article")
+	assertEqual(t, ogp.Images, []googp.Image{
+		{URL: "https://example.com/blog/images/1.png", SecureURL: "https://cdn.example.com/1.png"},
+		{URL: "http://example.org/2.png"},
+	})
+	assertEqual(t, ogp.Description, "/not/url")
+}
+
+func TestExtract_Invalid(t *testing.T) {
+	var results []*Result
+	for result := range Extract(context.Background(), strings.NewReader("invalid"), nil) {
+		results = append(results, result)
+	}
+	assertEqual(t, len(results), 1)
+	assertEqual(t, errors.Is(results[0].Err, ErrInvalidRecord), true)
+}
+
+func TestExtract_Cancel(t *testing.T) {
+	f, err := os.Open("testdata/example.warc")
+	assertNoError(t, err)
+	defer f.Close()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	results := Extract(ctx, f, nil)
+	<-results
+	cancel()
+
+	var count int
+	for range results {
+		count++
+	}
+	if count > 1 {
+		t.Errorf("Too many results after canceled: %d", count)
+	}
+}
@@ -0,0 +1,146 @@
+// Package warc is a reader of WARC (Web ARChive) files to extract OGP information with googp.
+//
+// Both of uncompressed and gzip-compressed files (including per-record compression) are supported.
+//
+// WARC: https://iipc.github.io/warc-specifications/specifications/warc-format/warc-1.1/
+package warc
+
+import (
+	"bufio"
+	"compress/gzip"
+	"errors"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"mime"
+	"net/http"
+	"net/textproto"
+	"net/url"
+	"strconv"
+	"strings"
+)
+
+var (
+	// ErrInvalidRecord is an error returned when the record is not a valid WARC record.
+	ErrInvalidRecord = errors.New("Invalid WARC record")
+	// ErrNotHTTPResponse is an error returned when the record is not an HTTP response.
+	ErrNotHTTPResponse = errors.New("Not an HTTP response record")
+)
+
+// Reader is a reader of WARC records.
+type Reader struct {
+	br      *bufio.Reader
+	current *Record
+}
+
+// Record is a WARC record.
+type Record struct {
+	// Version is the WARC version. (e.g. `WARC/1.0`)
+	Version string
+	// Header is the named fields of the record.
+	Header textproto.MIMEHeader
+	// Body is the content block of the record.
+	// It can be read until the next call of Reader.Next.
+	Body io.Reader
+}
+
+// NewReader create a `Reader`.
+// It returns an error when the gzip header is invalid.
+func NewReader(r io.Reader) (*Reader, error) {
+	br := bufio.NewReader(r)
+	if magic, _ := br.Peek(2); len(magic) == 2 && magic[0] == 0x1f && magic[1] == 0x8b {
+		// NOTE: gzip.Reader reads the concatenated members as a stream, so per-record compression is supported.
+		zr, err := gzip.NewReader(br)
+		if err != nil {
+			return nil, err
+		}
+		br = bufio.NewReader(zr)
+	}
+	return &Reader{br: br}, nil
+}
+
+// Next returns the next record. It returns io.EOF when there are no more records.
+func (r *Reader) Next() (*Record, error) {
+	if r.current != nil {
+		if _, err := io.Copy(ioutil.Discard, r.current.Body); err != nil {
+			return nil, err
+		}
+		r.current = nil
+	}
+
+	var version string
+	for version == "" {
+		line, err := r.br.ReadString('\n')
+		if err != nil {
+			if err == io.EOF && strings.TrimSpace(line) == "" {
+				return nil, io.EOF
+			}
+			return nil, fmt.Errorf("%w: %s", ErrInvalidRecord, err)
+		}
+		// NOTE: Skip the blank lines at the end of the previous record.
+		version = strings.TrimSpace(line)
+	}
+	if !strings.HasPrefix(version, "WARC/") {
+		return nil, fmt.Errorf("%w: unknown version line (%q)", ErrInvalidRecord, version)
+	}
+
+	header, err := textproto.NewReader(r.br).ReadMIMEHeader()
+	if err != nil {
+		return nil, fmt.Errorf("%w: %s", ErrInvalidRecord, err)
+	}
+	length, err := strconv.ParseInt(header.Get("Content-Length"), 10, 64)
+	if err != nil || length < 0 {
+		return nil, fmt.Errorf("%w: invalid Content-Length", ErrInvalidRecord)
+	}
+
+	r.current = &Record{
+		Version: version,
+		Header:  header,
+		Body:    io.LimitReader(r.br, length),
+	}
+	return r.current, nil
+}
+
+// Type returns the value of WARC-Type. (e.g. `response`)
+func (rec *Record) Type() string {
+	return rec.Header.Get("WARC-Type")
+}
+
+// TargetURI returns the value of WARC-Target-URI.
+func (rec *Record) TargetURI() string {
+	// NOTE: WARC/1.0 files written by some crawlers enclose the URI in angle brackets.
+	return strings.Trim(rec.Header.Get("WARC-Target-URI"), "<>")
+}
+
+// RecordID returns the value of WARC-Record-ID.
+func (rec *Record) RecordID() string {
+	return rec.Header.Get("WARC-Record-ID")
+}
+
+// IsHTTPResponse returns true when the record is a response record that has an HTTP response.
+func (rec *Record) IsHTTPResponse() bool {
+	if rec.Type() != "response" {
+		return false
+	}
+	mt, params, err := mime.ParseMediaType(rec.Header.Get("Content-Type"))
+	if err != nil || mt != "application/http" {
+		return false
+	}
+	msgtype, ok := params["msgtype"]
+	return !ok || msgtype == "response"
+}
+
+// HTTPResponse reconstructs the HTTP response from the body.
+// The request of the response is a GET request to WARC-Target-URI.
+func (rec *Record) HTTPResponse() (*http.Response, error) {
+	if !rec.IsHTTPResponse() {
+		return nil, ErrNotHTTPResponse
+	}
+
+	u, err := url.Parse(rec.TargetURI())
+	if err != nil {
+		return nil, fmt.Errorf("%w: invalid WARC-Target-URI: %s", ErrInvalidRecord, err)
+	}
+	req := &http.Request{Method: "GET", URL: u, Header: http.Header{}}
+	return http.ReadResponse(bufio.NewReader(rec.Body), req)
+}
@@ -0,0 +1,127 @@
+package warc
+
+import (
+	"errors"
+	"io"
+	"io/ioutil"
+	"os"
+	"reflect"
+	"runtime"
+	"strings"
+	"testing"
+)
+
+func TestReader(t *testing.T) {
+	for _, name := range []string{"testdata/example.warc", "testdata/example.warc.gz"} {
+		f, err := os.Open(name)
+		assertNoError(t, err)
+		defer f.Close()
+
+		reader, err := NewReader(f)
+		assertNoError(t, err)
+
+		var types, uris []string
+		for {
+			rec, err := reader.Next()
+			if err == io.EOF {
+				break
+			}
+			assertNoError(t, err)
+			assertEqual(t, rec.Version, "WARC/1.0")
+			types = append(types, rec.Type())
+			uris = append(uris, rec.TargetURI())
+		}
+		assertEqual(t, types, []string{"warcinfo", "request", "response", "metadata", "response", "response"})
+		assertEqual(t, uris, []string{
+			"",
+			"http://example.com/1.html",
+			"http://example.com/1.html",
+			"http://example.com/1.html",
+			"http://example.com/6.html",
+			"http://example.com/notfound.html",
+		})
+	}
+}
+
+func TestRecord_HTTPResponse(t *testing.T) {
+	f, err := os.Open("testdata/example.warc")
+	assertNoError(t, err)
+	defer f.Close()
+
+	reader, err := NewReader(f)
+	assertNoError(t, err)
+
+	rec, err := reader.Next() // warcinfo
+	assertNoError(t, err)
+	assertEqual(t, rec.IsHTTPResponse(), false)
+	data, err := ioutil.ReadAll(rec.Body)
+	assertNoError(t, err)
+	assertEqual(t, string(data), "software: googp-test\r\nformat: WARC File Format 1.0\r\n")
+
+	rec, err = reader.Next() // request
+	assertNoError(t, err)
+	assertEqual(t, rec.IsHTTPResponse(), false)
+	_, err = rec.HTTPResponse()
+	assertEqual(t, err, ErrNotHTTPResponse)
+
+	rec, err = reader.Next() // response
+	assertNoError(t, err)
+	assertEqual(t, rec.IsHTTPResponse(), true)
+	assertEqual(t, rec.RecordID(), "<urn:uuid:00000000-0000-0000-0000-000000000003>")
+
+	res, err := rec.HTTPResponse()
+	assertNoError(t, err)
+	assertEqual(t, res.StatusCode, 200)
+	assertEqual(t, res.Header.Get("Content-Type"), "text/html")
+	assertEqual(t, res.Request.URL.String(), "http://example.com/1.html")
+	data, err = ioutil.ReadAll(res.Body)
+	assertNoError(t, err)
+	assertEqual(t, strings.Contains(string(data), `<meta property="og:title" content="title" />`), true)
+}
+
+func TestReader_Invalid(t *testing.T) {
+	reader, err := NewReader(strings.NewReader("HTTP/1.1 200 OK\r\n\r\n"))
+	assertNoError(t, err)
+	_, err = reader.Next()
+	assertEqual(t, errors.Is(err, ErrInvalidRecord), true)
+
+	reader, err = NewReader(strings.NewReader("WARC/1.0\r\nWARC-Type: response\r\n\r\n"))
+	assertNoError(t, err)
+	_, err = reader.Next()
+	assertEqual(t, errors.Is(err, ErrInvalidRecord), true)
+
+	reader, err = NewReader(strings.NewReader(""))
+	assertNoError(t, err)
+	_, err = reader.Next()
+	assertEqual(t, err, io.EOF)
+
+	_, err = NewReader(strings.NewReader("\x1f\x8b"))
+	assertError(t, err)
+}
+
+func assertEqual(t *testing.T, got, expected interface{}) bool {
+	if !reflect.DeepEqual(got, expected) {
+		_, file, line, _ := runtime.Caller(1)
+		t.Errorf("Not equals:\n  file    : %s:%d\n  got     : %#v\n  expected: %#v\n", file, line, got, expected)
+		return false
+	}
+	return true
+}
+
+func assertError(t *testing.T, err error) bool {
+	if err == nil {
+		_, file, line, _ := runtime.Caller(1)
+		t.Errorf("NoError:\n  file    : %s:%d\n", file, line)
+		return false
+	}
+	return true
+}
+
+func assertNoError(t *testing.T, err error) bool {
+	if err != nil {
+		_, file, line, _ := runtime.Caller(1)
+		t.Errorf("Error:\n  file    : %s:%d\n  error   : %#v\n", file, line, err)
+		return false
+	}
+	return true
+}